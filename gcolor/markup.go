@@ -0,0 +1,157 @@
+package gcolor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tagCodes 标记语言支持的标签及对应 SGR 码
+var tagCodes = map[string]Color{
+	"bold":      Bold,
+	"b":         Bold,
+	"dim":       "2",
+	"italic":    "3",
+	"i":         "3",
+	"underline": "4",
+	"u":         "4",
+	"blink":     "5",
+	"reverse":   "7",
+	"hidden":    "8",
+	"strike":    "9",
+	"s":         "9",
+
+	"black":        "30",
+	"red":          RED,
+	"green":        GREEN,
+	"yellow":       YELLOW,
+	"blue":         BLUE,
+	"magenta":      MAGENTA,
+	"cyan":         CYAN,
+	"white":        "97",
+	"gray":         LightGray,
+	"lightgray":    LightGray,
+	"darkgray":     DarkGray,
+	"lightred":     LightRed,
+	"lightgreen":   LightGreen,
+	"lightyellow":  LightYellow,
+	"lightblue":    LightBlue,
+	"lightmagenta": LightMagenta,
+	"lightcyan":    LightCyan,
+
+	"bg-black":   "40",
+	"bg-red":     "41",
+	"bg-green":   "42",
+	"bg-yellow":  "43",
+	"bg-blue":    "44",
+	"bg-magenta": "45",
+	"bg-cyan":    "46",
+	"bg-white":   "47",
+}
+
+// MarkupError 标记解析错误，Pos 为出错位置（字节偏移）
+type MarkupError struct {
+	Pos int
+	Msg string
+}
+
+func (e *MarkupError) Error() string {
+	return fmt.Sprintf("gcolor: markup error at %d: %s", e.Pos, e.Msg)
+}
+
+// Render 渲染颜色标记，支持嵌套
+// 例：Render("<red>error</red> in <bold>{{file}}</bold>", map[string]interface{}{"file": "a.go"})
+// 标签：<red>…</red>、<#ff8800>…</#ff8800>、<orange>…</orange>，</> 关闭最近一个标签；\< \{ \\ 转义；{{key}} 从 data 取值并原样输出
+func Render(markup string, data ...map[string]interface{}) (string, error) {
+	var vars map[string]interface{}
+	if len(data) > 0 {
+		vars = data[0]
+	}
+	var sb strings.Builder
+	var stack []string
+	for i := 0; i < len(markup); {
+		c := markup[i]
+		switch {
+		case c == '\\' && i+1 < len(markup):
+			sb.WriteByte(markup[i+1])
+			i += 2
+		case c == '{' && strings.HasPrefix(markup[i:], "{{"):
+			j := strings.Index(markup[i+2:], "}}")
+			if j < 0 {
+				return "", &MarkupError{Pos: i, Msg: "unclosed placeholder"}
+			}
+			key := strings.TrimSpace(markup[i+2 : i+2+j])
+			v, ok := vars[key]
+			if !ok {
+				return "", &MarkupError{Pos: i, Msg: fmt.Sprintf("unknown placeholder %q", key)}
+			}
+			sb.WriteString(fmt.Sprint(v))
+			i += 2 + j + 2
+		case c == '<':
+			j := strings.IndexByte(markup[i:], '>')
+			if j < 0 {
+				return "", &MarkupError{Pos: i, Msg: "unclosed tag"}
+			}
+			tag := strings.ToLower(strings.TrimSpace(markup[i+1 : i+j]))
+			if strings.HasPrefix(tag, "/") {
+				name := strings.TrimSpace(tag[1:])
+				if len(stack) == 0 {
+					return "", &MarkupError{Pos: i, Msg: fmt.Sprintf("unexpected closing tag </%s>", name)}
+				}
+				if name != "" && name != stack[len(stack)-1] {
+					return "", &MarkupError{Pos: i, Msg: fmt.Sprintf("closing tag </%s> does not match <%s>", name, stack[len(stack)-1])}
+				}
+				stack = stack[:len(stack)-1]
+				sb.WriteString(esc + string(Reset) + "m")
+				for _, t := range stack {
+					code, _ := tagCode(t)
+					sb.WriteString(esc + code + "m")
+				}
+			} else {
+				code, ok := tagCode(tag)
+				if !ok {
+					return "", &MarkupError{Pos: i, Msg: fmt.Sprintf("unknown tag <%s>", tag)}
+				}
+				stack = append(stack, tag)
+				sb.WriteString(esc + code + "m")
+			}
+			i += j + 1
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+	if len(stack) > 0 {
+		return "", &MarkupError{Pos: len(markup), Msg: fmt.Sprintf("unclosed tag <%s>", stack[len(stack)-1])}
+	}
+	return sb.String(), nil
+}
+
+// tagCode 内置标签优先，其次 #rrggbb 与 CSS 命名颜色
+func tagCode(tag string) (string, bool) {
+	if c, ok := tagCodes[tag]; ok {
+		return string(c), true
+	}
+	if strings.HasPrefix(tag, "#") {
+		c, err := ParseHex(tag)
+		return c.code(), err == nil
+	}
+	if c, ok := LookupName(tag); ok {
+		return c.code(), true
+	}
+	return "", false
+}
+
+// MustRender 同 Render，出错时 panic
+func MustRender(markup string, data ...map[string]interface{}) string {
+	s, err := Render(markup, data...)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Escape 转义文本中的标记字符，使其可安全嵌入标记
+func Escape(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, "<", `\<`, "{", `\{`)
+	return r.Replace(s)
+}
@@ -0,0 +1,262 @@
+package gcolor
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// ColorMode 终端颜色能力
+type ColorMode int32
+
+const (
+	Mode16        ColorMode = iota // 16 色
+	Mode256                        // 256 色
+	ModeTrueColor                  // 24 位真彩色
+)
+
+var colorMode atomic.Int32
+
+func init() {
+	colorMode.Store(int32(DetectColorMode()))
+}
+
+// DetectColorMode 根据 COLORTERM / TERM 环境变量探测终端颜色能力
+func DetectColorMode() ColorMode {
+	ct := strings.ToLower(os.Getenv("COLORTERM"))
+	if ct == "truecolor" || ct == "24bit" {
+		return ModeTrueColor
+	}
+	term := strings.ToLower(os.Getenv("TERM"))
+	switch {
+	case strings.Contains(term, "truecolor"), strings.Contains(term, "24bit"), strings.Contains(term, "direct"):
+		return ModeTrueColor
+	case strings.Contains(term, "256"):
+		return Mode256
+	}
+	return Mode16
+}
+
+// SetColorMode 手动指定颜色模式（覆盖自动探测结果）
+func SetColorMode(m ColorMode) { colorMode.Store(int32(m)) }
+
+// GetColorMode 当前颜色模式
+func GetColorMode() ColorMode { return ColorMode(colorMode.Load()) }
+
+// RGBColor 24 位颜色值
+type RGBColor struct {
+	R, G, B uint8
+}
+
+// String 返回 #rrggbb 形式
+func (c RGBColor) String() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// Sprint 按当前颜色模式着色，终端不支持真彩色时自动降级到 256 / 16 色
+func (c RGBColor) Sprint(v ...interface{}) string {
+	return esc + c.code() + "m" + fmt.Sprint(v...) + esc + string(Reset) + "m"
+}
+
+// code 返回当前颜色模式下的前景色 SGR 码
+func (c RGBColor) code() string {
+	switch GetColorMode() {
+	case ModeTrueColor:
+		return "38;2;" + strconv.Itoa(int(c.R)) + ";" + strconv.Itoa(int(c.G)) + ";" + strconv.Itoa(int(c.B))
+	case Mode256:
+		return "38;5;" + strconv.Itoa(c.To256())
+	}
+	return string(c.To16())
+}
+
+// ParseHex 解析 #rgb / #rrggbb（# 可省略）
+func ParseHex(s string) (RGBColor, error) {
+	h := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(h) == 3 {
+		h = string([]byte{h[0], h[0], h[1], h[1], h[2], h[2]})
+	}
+	if len(h) != 6 {
+		return RGBColor{}, fmt.Errorf("gcolor: invalid hex color %q", s)
+	}
+	n, err := strconv.ParseUint(h, 16, 32)
+	if err != nil {
+		return RGBColor{}, fmt.Errorf("gcolor: invalid hex color %q", s)
+	}
+	return RGBColor{R: uint8(n >> 16), G: uint8(n >> 8), B: uint8(n)}, nil
+}
+
+// FromHSL h 为色相 [0,360)，s、l 为饱和度与亮度 [0,1]
+func FromHSL(h, s, l float64) RGBColor {
+	h = math.Mod(h, 360)
+	if h < 0 {
+		h += 360
+	}
+	s = clamp01(s)
+	l = clamp01(l)
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := l - c/2
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	return RGBColor{R: to8(r + m), G: to8(g + m), B: to8(b + m)}
+}
+
+// LookupName 查找 CSS 命名颜色（不区分大小写）
+func LookupName(name string) (RGBColor, bool) {
+	c, ok := cssColors[strings.ToLower(strings.TrimSpace(name))]
+	return c, ok
+}
+
+// Hex 使用十六进制颜色着色，如 Hex("#ff8800", "text")；颜色非法时原样输出
+func Hex(hex string, v ...interface{}) string {
+	c, err := ParseHex(hex)
+	if err != nil {
+		return fmt.Sprint(v...)
+	}
+	return c.Sprint(v...)
+}
+
+// HSL 使用 HSL 颜色着色
+func HSL(h, s, l float64, v ...interface{}) string {
+	return FromHSL(h, s, l).Sprint(v...)
+}
+
+// Named 使用 CSS 命名颜色着色，如 Named("orange", "text")；未知名称时原样输出
+func Named(name string, v ...interface{}) string {
+	c, ok := LookupName(name)
+	if !ok {
+		return fmt.Sprint(v...)
+	}
+	return c.Sprint(v...)
+}
+
+// Gradient 按字符在 from 与 to 之间线性插值着色，适合横幅标题
+func Gradient(text string, from, to RGBColor) string {
+	runes := []rune(text)
+	if len(runes) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	last := ""
+	for i, r := range runes {
+		t := 0.0
+		if len(runes) > 1 {
+			t = float64(i) / float64(len(runes)-1)
+		}
+		c := RGBColor{
+			R: lerp(from.R, to.R, t),
+			G: lerp(from.G, to.G, t),
+			B: lerp(from.B, to.B, t),
+		}
+		// 降级后相邻字符常落在同一色号，合并以减少转义序列
+		if code := c.code(); code != last {
+			sb.WriteString(esc + code + "m")
+			last = code
+		}
+		sb.WriteRune(r)
+	}
+	sb.WriteString(esc + string(Reset) + "m")
+	return sb.String()
+}
+
+// To256 转为最接近的 xterm 256 色索引（16~255）
+func (c RGBColor) To256() int {
+	// 6x6x6 色块
+	levels := [6]int{0, 95, 135, 175, 215, 255}
+	idx := func(v uint8) int {
+		if v < 48 {
+			return 0
+		}
+		if v < 115 {
+			return 1
+		}
+		return (int(v) - 35) / 40
+	}
+	ri, gi, bi := idx(c.R), idx(c.G), idx(c.B)
+	cube := RGBColor{uint8(levels[ri]), uint8(levels[gi]), uint8(levels[bi])}
+	cubeIdx := 16 + 36*ri + 6*gi + bi
+	// 灰阶 232~255
+	avg := (int(c.R) + int(c.G) + int(c.B)) / 3
+	grayIdx := 23
+	if avg <= 238 {
+		grayIdx = (avg - 3) / 10
+		if grayIdx < 0 {
+			grayIdx = 0
+		}
+	}
+	gv := uint8(8 + 10*grayIdx)
+	gray := RGBColor{gv, gv, gv}
+	if dist(c, gray) < dist(c, cube) {
+		return 232 + grayIdx
+	}
+	return cubeIdx
+}
+
+// ansi16 标准 16 色调色板（xterm 默认值）
+var ansi16 = [16]struct {
+	code Color
+	rgb  RGBColor
+}{
+	{"30", RGBColor{0, 0, 0}},
+	{"31", RGBColor{205, 0, 0}},
+	{"32", RGBColor{0, 205, 0}},
+	{"33", RGBColor{205, 205, 0}},
+	{"34", RGBColor{0, 0, 238}},
+	{"35", RGBColor{205, 0, 205}},
+	{"36", RGBColor{0, 205, 205}},
+	{"37", RGBColor{229, 229, 229}},
+	{"90", RGBColor{127, 127, 127}},
+	{"91", RGBColor{255, 0, 0}},
+	{"92", RGBColor{0, 255, 0}},
+	{"93", RGBColor{255, 255, 0}},
+	{"94", RGBColor{92, 92, 255}},
+	{"95", RGBColor{255, 0, 255}},
+	{"96", RGBColor{0, 255, 255}},
+	{"97", RGBColor{255, 255, 255}},
+}
+
+// To16 转为最接近的 16 色前景色码
+func (c RGBColor) To16() Color {
+	best, bestDist := ansi16[0].code, math.MaxInt
+	for _, p := range ansi16 {
+		if d := dist(c, p.rgb); d < bestDist {
+			best, bestDist = p.code, d
+		}
+	}
+	return best
+}
+
+func dist(a, b RGBColor) int {
+	dr := int(a.R) - int(b.R)
+	dg := int(a.G) - int(b.G)
+	db := int(a.B) - int(b.B)
+	return dr*dr + dg*dg + db*db
+}
+
+func lerp(a, b uint8, t float64) uint8 {
+	return uint8(math.Round(float64(a) + (float64(b)-float64(a))*t))
+}
+
+func clamp01(f float64) float64 {
+	return math.Max(0, math.Min(1, f))
+}
+
+func to8(f float64) uint8 {
+	return uint8(math.Round(clamp01(f) * 255))
+}
@@ -0,0 +1,123 @@
+package gcolor
+
+import (
+	"regexp"
+	"unicode"
+)
+
+// ansiRe 匹配 CSI（\x1b[...m 等）与 OSC（\x1b]...BEL/ST）转义序列
+var ansiRe = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[@-Z\\-_]`)
+
+// StripANSI 去除字符串中的 ANSI 转义序列
+func StripANSI(s string) string {
+	return ansiRe.ReplaceAllString(s, "")
+}
+
+// VisibleWidth 终端显示宽度：忽略转义序列，东亚宽字符计 2 列，组合字符计 0 列
+func VisibleWidth(s string) int {
+	w := 0
+	for _, r := range StripANSI(s) {
+		w += RuneWidth(r)
+	}
+	return w
+}
+
+// RuneWidth 单个字符的显示宽度（0、1 或 2）
+func RuneWidth(r rune) int {
+	switch {
+	case r == 0, r < 32, r >= 0x7f && r < 0xa0:
+		return 0
+	case r == 0x200b, r == 0x200c, r == 0x200d, r == 0xfeff:
+		return 0
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case isWide(r):
+		return 2
+	}
+	return 1
+}
+
+// wideTable East Asian Wide (W) 与 Fullwidth (F) 区间
+var wideTable = [][2]rune{
+	{0x1100, 0x115f},   // 谚文字母
+	{0x231a, 0x231b},   // 表情符号
+	{0x2329, 0x232a},   // 尖括号
+	{0x23e9, 0x23ec},   // 媒体控制符号
+	{0x23f0, 0x23f0},   // 闹钟
+	{0x23f3, 0x23f3},   // 沙漏
+	{0x25fd, 0x25fe},   // 方块
+	{0x2614, 0x2615},   // 伞、热饮
+	{0x2648, 0x2653},   // 星座
+	{0x267f, 0x267f},   // 轮椅
+	{0x2693, 0x2693},   // 锚
+	{0x26a1, 0x26a1},   // 高压
+	{0x26aa, 0x26ab},   // 圆
+	{0x26bd, 0x26be},   // 足球、棒球
+	{0x26c4, 0x26c5},   // 雪人、太阳
+	{0x26ce, 0x26ce},   // 蛇夫座
+	{0x26d4, 0x26d4},   // 禁止通行
+	{0x26ea, 0x26ea},   // 教堂
+	{0x26f2, 0x26f3},   // 喷泉、高尔夫
+	{0x26f5, 0x26f5},   // 帆船
+	{0x26fa, 0x26fa},   // 帐篷
+	{0x26fd, 0x26fd},   // 加油站
+	{0x2705, 0x2705},   // 勾
+	{0x270a, 0x270b},   // 手势
+	{0x2728, 0x2728},   // 闪光
+	{0x274c, 0x274c},   // 叉
+	{0x274e, 0x274e},   // 叉
+	{0x2753, 0x2755},   // 问号、叹号
+	{0x2757, 0x2757},   // 叹号
+	{0x2795, 0x2797},   // 加减除
+	{0x27b0, 0x27b0},   // 卷曲环
+	{0x27bf, 0x27bf},   // 双卷曲环
+	{0x2b1b, 0x2b1c},   // 大方块
+	{0x2b50, 0x2b50},   // 星
+	{0x2b55, 0x2b55},   // 圆
+	{0x2e80, 0x303e},   // CJK 部首、康熙部首、CJK 符号和标点
+	{0x3041, 0x33ff},   // 平假名、片假名、注音、CJK 兼容
+	{0x3400, 0x4dbf},   // CJK 扩展 A
+	{0x4e00, 0x9fff},   // CJK 统一汉字
+	{0xa000, 0xa4cf},   // 彝文
+	{0xa960, 0xa97f},   // 谚文扩展 A
+	{0xac00, 0xd7a3},   // 谚文音节
+	{0xf900, 0xfaff},   // CJK 兼容汉字
+	{0xfe10, 0xfe19},   // 竖排标点
+	{0xfe30, 0xfe6f},   // CJK 兼容形式、小写变体
+	{0xff00, 0xff60},   // 全角 ASCII
+	{0xffe0, 0xffe6},   // 全角符号
+	{0x16fe0, 0x16fe4}, // 西夏文等符号
+	{0x17000, 0x18cff}, // 西夏文
+	{0x1b000, 0x1b2ff}, // 假名补充
+	{0x1f004, 0x1f004}, // 麻将
+	{0x1f0cf, 0x1f0cf}, // 扑克
+	{0x1f18e, 0x1f18e}, // AB 血型
+	{0x1f191, 0x1f19a}, // 方框字母
+	{0x1f200, 0x1f251}, // 带框汉字
+	{0x1f300, 0x1f64f}, // 杂项符号与表情
+	{0x1f680, 0x1f6ff}, // 交通与地图
+	{0x1f7e0, 0x1f7eb}, // 彩色圆与方块
+	{0x1f90c, 0x1f9ff}, // 补充符号与表情
+	{0x1fa70, 0x1faff}, // 扩展表情
+	{0x20000, 0x2fffd}, // CJK 扩展 B~F
+	{0x30000, 0x3fffd}, // CJK 扩展 G
+}
+
+func isWide(r rune) bool {
+	if r < wideTable[0][0] {
+		return false
+	}
+	lo, hi := 0, len(wideTable)-1
+	for lo <= hi {
+		mid := (lo + hi) / 2
+		switch {
+		case r < wideTable[mid][0]:
+			hi = mid - 1
+		case r > wideTable[mid][1]:
+			lo = mid + 1
+		default:
+			return true
+		}
+	}
+	return false
+}
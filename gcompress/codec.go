@@ -0,0 +1,245 @@
+package gcompress
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+// Compression 压缩格式
+type Compression string
+
+const (
+	CompressNone   Compression = ""
+	CompressGzip   Compression = "gzip"
+	CompressZlib   Compression = "zlib"
+	CompressFlate  Compression = "flate"
+	CompressZstd   Compression = "zstd"
+	CompressSnappy Compression = "snappy"
+	CompressLZ4    Compression = "lz4"
+	CompressBrotli Compression = "brotli"
+)
+
+var (
+	// ErrUnknownFormat 无法识别数据的压缩格式
+	ErrUnknownFormat = errors.New("gcompress: unknown compression format")
+	// ErrDecodeOnly 该格式只支持解压
+	ErrDecodeOnly = errors.New("gcompress: codec is decode-only")
+)
+
+// Codec 流式压缩编解码器
+type Codec interface {
+	// Name 格式名称
+	Name() Compression
+	// NewWriter 压缩写入 w，level 为 0 表示默认级别；Close 只结束压缩流，不关闭 w
+	NewWriter(w io.Writer, level int) (io.WriteCloser, error)
+	// NewReader 从 r 读取解压后的数据
+	NewReader(r io.Reader) (io.ReadCloser, error)
+	// Match 根据数据头判断是否为该格式，无魔数的格式始终返回 false
+	Match(head []byte) bool
+}
+
+var (
+	codecMu sync.RWMutex
+	codecs  = make(map[Compression]Codec)
+	order   []Compression // 注册顺序，即识别顺序
+)
+
+// Register 注册编解码器，同名覆盖
+func Register(c Codec) {
+	codecMu.Lock()
+	defer codecMu.Unlock()
+	if _, ok := codecs[c.Name()]; !ok {
+		order = append(order, c.Name())
+	}
+	codecs[c.Name()] = c
+}
+
+// GetCodec 按名称获取编解码器
+func GetCodec(name Compression) (Codec, error) {
+	codecMu.RLock()
+	defer codecMu.RUnlock()
+	c, ok := codecs[name]
+	if !ok {
+		return nil, fmt.Errorf("gcompress: unsupported compression %q", name)
+	}
+	return c, nil
+}
+
+// NewWriter 以指定格式压缩写入 w，CompressNone 时原样写入
+func NewWriter(w io.Writer, name Compression, level int) (io.WriteCloser, error) {
+	if name == CompressNone {
+		return nopWriteCloser{w}, nil
+	}
+	c, err := GetCodec(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.NewWriter(w, level)
+}
+
+// NewReader 按数据头自动识别格式并解压，无法识别时返回 ErrUnknownFormat
+// 可通过 name 指定格式（用于 flate、brotli 等无魔数的格式）
+func NewReader(r io.Reader, name ...Compression) (io.ReadCloser, error) {
+	if len(name) > 0 {
+		if name[0] == CompressNone {
+			return io.NopCloser(r), nil
+		}
+		c, err := GetCodec(name[0])
+		if err != nil {
+			return nil, err
+		}
+		return c.NewReader(r)
+	}
+	c, br, err := Detect(r)
+	if err != nil {
+		return nil, err
+	}
+	if c == CompressNone {
+		return nil, ErrUnknownFormat
+	}
+	return NewReader(br, c)
+}
+
+// Detect 读取数据头识别压缩格式，返回的 io.Reader 包含已读取的数据头
+// 无法识别时返回 CompressNone
+func Detect(r io.Reader) (Compression, io.Reader, error) {
+	br := bufio.NewReader(r)
+	head, err := br.Peek(16)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return CompressNone, br, err
+	}
+	codecMu.RLock()
+	defer codecMu.RUnlock()
+	for _, name := range order {
+		if codecs[name].Match(head) {
+			return name, br, nil
+		}
+	}
+	return CompressNone, br, nil
+}
+
+// nopWriteCloser 不压缩时的包装
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// codec 内置编解码器
+type codec struct {
+	name   Compression
+	match  func(head []byte) bool
+	writer func(w io.Writer, level int) (io.WriteCloser, error)
+	reader func(r io.Reader) (io.ReadCloser, error)
+}
+
+func (c *codec) Name() Compression { return c.name }
+
+func (c *codec) Match(head []byte) bool { return c.match != nil && c.match(head) }
+
+func (c *codec) NewWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	if c.writer == nil {
+		return nil, ErrDecodeOnly
+	}
+	return c.writer(w, level)
+}
+
+func (c *codec) NewReader(r io.Reader) (io.ReadCloser, error) { return c.reader(r) }
+
+func hasPrefix(magic ...byte) func([]byte) bool {
+	return func(head []byte) bool {
+		return len(head) >= len(magic) && string(head[:len(magic)]) == string(magic)
+	}
+}
+
+func init() {
+	Register(&codec{
+		name:  CompressGzip,
+		match: hasPrefix(0x1f, 0x8b),
+		writer: func(w io.Writer, level int) (io.WriteCloser, error) {
+			if level == 0 {
+				level = gzip.DefaultCompression
+			}
+			return gzip.NewWriterLevel(w, level)
+		},
+		reader: func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) },
+	})
+	Register(&codec{
+		name:  CompressZstd,
+		match: hasPrefix(0x28, 0xb5, 0x2f, 0xfd),
+		writer: func(w io.Writer, level int) (io.WriteCloser, error) {
+			lv := zstd.SpeedDefault
+			if level != 0 {
+				lv = zstd.EncoderLevelFromZstd(level)
+			}
+			return zstd.NewWriter(w, zstd.WithEncoderLevel(lv))
+		},
+		reader: func(r io.Reader) (io.ReadCloser, error) {
+			zr, err := zstd.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			return zr.IOReadCloser(), nil
+		},
+	})
+	Register(&codec{
+		name:  CompressLZ4,
+		match: hasPrefix(0x04, 0x22, 0x4d, 0x18),
+		writer: func(w io.Writer, level int) (io.WriteCloser, error) {
+			lw := lz4.NewWriter(w)
+			if level > 0 {
+				if level > 9 {
+					level = 9
+				}
+				if err := lw.Apply(lz4.CompressionLevelOption(lz4.CompressionLevel(1 << (8 + level)))); err != nil {
+					return nil, err
+				}
+			}
+			return lw, nil
+		},
+		reader: func(r io.Reader) (io.ReadCloser, error) { return io.NopCloser(lz4.NewReader(r)), nil },
+	})
+	Register(&codec{
+		name:   CompressSnappy,
+		match:  hasPrefix(0xff, 0x06, 0x00, 0x00, 's', 'N', 'a', 'P', 'p', 'Y'),
+		writer: func(w io.Writer, level int) (io.WriteCloser, error) { return snappy.NewBufferedWriter(w), nil },
+		reader: func(r io.Reader) (io.ReadCloser, error) { return io.NopCloser(snappy.NewReader(r)), nil },
+	})
+	Register(&codec{
+		name: CompressZlib,
+		// CMF 低 4 位为 8（deflate），且 CMF*256+FLG 能被 31 整除
+		match: func(head []byte) bool {
+			return len(head) >= 2 && head[0]&0x0f == 8 && head[0]>>4 <= 7 && (uint16(head[0])<<8|uint16(head[1]))%31 == 0
+		},
+		writer: func(w io.Writer, level int) (io.WriteCloser, error) {
+			if level == 0 {
+				level = zlib.DefaultCompression
+			}
+			return zlib.NewWriterLevel(w, level)
+		},
+		reader: func(r io.Reader) (io.ReadCloser, error) { return zlib.NewReader(r) },
+	})
+	Register(&codec{
+		name: CompressFlate,
+		writer: func(w io.Writer, level int) (io.WriteCloser, error) {
+			if level == 0 {
+				level = flate.DefaultCompression
+			}
+			return flate.NewWriter(w, level)
+		},
+		reader: func(r io.Reader) (io.ReadCloser, error) { return flate.NewReader(r), nil },
+	})
+	Register(&codec{
+		name:   CompressBrotli,
+		reader: func(r io.Reader) (io.ReadCloser, error) { return io.NopCloser(brotli.NewReader(r)), nil },
+	})
+}
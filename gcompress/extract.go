@@ -0,0 +1,406 @@
+package gcompress
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrIllegalPath 条目路径越界（Zip Slip）或为绝对路径
+	ErrIllegalPath = errors.New("gcompress: illegal entry path")
+	// ErrSymlink 不允许的符号链接（未开启或指向解压目录之外）
+	ErrSymlink = errors.New("gcompress: illegal symlink")
+	// ErrTooManyEntries 条目数超过限制
+	ErrTooManyEntries = errors.New("gcompress: too many entries")
+	// ErrSizeLimit 解压后总大小超过限制
+	ErrSizeLimit = errors.New("gcompress: uncompressed size limit exceeded")
+)
+
+// ExtractOptions 解压选项
+type ExtractOptions struct {
+	// 解压后总字节数上限（0 表示不限制），防压缩炸弹
+	MaxTotalSize int64
+	// 条目数上限（0 表示不限制）
+	MaxEntries int
+	// 是否还原符号链接；链接在其余条目之后创建，并按最终目录状态确认目标位于解压目录内
+	AllowSymlinks bool
+	// 仅解压匹配的条目（glob，空表示全部）
+	Include []string
+	// 跳过匹配的条目（glob）
+	Exclude []string
+	// 还原属主 uid/gid（仅 tar，需要相应权限）
+	PreserveOwner bool
+}
+
+// DefaultExtractOptions 默认解压选项
+var DefaultExtractOptions = ExtractOptions{}
+
+// extractor 在目标目录内安全地创建文件、目录和链接
+type extractor struct {
+	root    string
+	opt     ExtractOptions
+	filter  *filter
+	entries int
+	written int64
+	dirs    []dirMeta // 目录的权限与时间最后设置，避免只读目录阻塞子条目写入
+	links   []*linkMeta
+	pending map[string]*linkMeta // 尚未创建的符号链接，按目标路径索引
+}
+
+type dirMeta struct {
+	path  string
+	mode  os.FileMode
+	mtime time.Time
+}
+
+// linkMeta 延后创建的符号链接；dropped 表示已被后续同名条目覆盖
+type linkMeta struct {
+	path     string
+	target   string
+	uid, gid int
+	owner    bool
+	dropped  bool
+}
+
+func newExtractor(dst string, opt ExtractOptions) (*extractor, error) {
+	root, err := filepath.Abs(dst)
+	if err != nil {
+		return nil, err
+	}
+	f, err := newFilter(opt.Include, opt.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+	return &extractor{root: root, opt: opt, filter: f, pending: map[string]*linkMeta{}}, nil
+}
+
+// countEntry 累计条目数并检查上限
+func (e *extractor) countEntry() error {
+	e.entries++
+	if e.opt.MaxEntries > 0 && e.entries > e.opt.MaxEntries {
+		return ErrTooManyEntries
+	}
+	return nil
+}
+
+// checkDeclared 根据头部声明的大小提前拒绝，实际写入时仍按真实字节数限制
+func (e *extractor) checkDeclared(size int64) error {
+	if e.opt.MaxTotalSize > 0 && (size < 0 || e.written+size > e.opt.MaxTotalSize) {
+		return ErrSizeLimit
+	}
+	return nil
+}
+
+// target 校验条目名并返回目标绝对路径；被过滤的条目返回空串
+func (e *extractor) target(name string) (string, error) {
+	rel, err := cleanEntryName(name)
+	if err != nil {
+		return "", err
+	}
+	if !e.filter.keep(rel) {
+		return "", nil
+	}
+	p, err := e.join(rel)
+	if err != nil {
+		return "", err
+	}
+	// 后出现的同名条目覆盖尚未创建的链接
+	if l, ok := e.pending[p]; ok {
+		l.dropped = true
+		delete(e.pending, p)
+	}
+	return p, nil
+}
+
+func (e *extractor) join(rel string) (string, error) {
+	p := filepath.Join(e.root, filepath.FromSlash(rel))
+	if err := e.checkParents(p); err != nil {
+		return "", err
+	}
+	return p, nil
+}
+
+// cleanEntryName 规范化条目名，拒绝绝对路径与 .. 越界
+func cleanEntryName(name string) (string, error) {
+	n := strings.ReplaceAll(name, "\\", "/")
+	if n == "" || strings.ContainsRune(n, 0) || strings.HasPrefix(n, "/") ||
+		filepath.IsAbs(name) || filepath.VolumeName(name) != "" || (len(n) >= 2 && n[1] == ':') {
+		return "", fmt.Errorf("%w: %q", ErrIllegalPath, name)
+	}
+	c := path.Clean(n)
+	if c == "." || c == ".." || strings.HasPrefix(c, "../") {
+		return "", fmt.Errorf("%w: %q", ErrIllegalPath, name)
+	}
+	return c, nil
+}
+
+// checkParents 确认 p 的各级父目录都不是符号链接（包括尚未创建的），防止经由链接写到目录之外
+func (e *extractor) checkParents(p string) error {
+	rel, err := filepath.Rel(e.root, filepath.Dir(p))
+	if err != nil {
+		return err
+	}
+	if rel == "." {
+		return nil
+	}
+	cur := e.root
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		cur = filepath.Join(cur, part)
+		if _, ok := e.pending[cur]; ok {
+			return fmt.Errorf("%w: %s is a symlink", ErrSymlink, cur)
+		}
+		fi, err := os.Lstat(cur)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("%w: %s is a symlink", ErrSymlink, cur)
+		}
+	}
+	return nil
+}
+
+// mkdir 创建目录，权限与时间在 finish 中设置
+func (e *extractor) mkdir(p string, mode os.FileMode, mtime time.Time) error {
+	if fi, err := os.Lstat(p); err == nil && !fi.IsDir() {
+		if err := os.Remove(p); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(p, 0755); err != nil {
+		return err
+	}
+	e.dirs = append(e.dirs, dirMeta{path: p, mode: permOr(mode, 0755), mtime: mtime})
+	return nil
+}
+
+// writeFile 写入普通文件，按实际写入字节数限制总大小
+func (e *extractor) writeFile(p string, r io.Reader, mode os.FileMode, mtime time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	// 已存在的同名链接或目录先删除，避免跟随链接写出目录
+	if fi, err := os.Lstat(p); err == nil && (fi.Mode()&os.ModeSymlink != 0 || fi.IsDir()) {
+		if err := os.RemoveAll(p); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, permOr(mode, 0644))
+	if err != nil {
+		return err
+	}
+	var src io.Reader = r
+	if e.opt.MaxTotalSize > 0 {
+		src = io.LimitReader(r, e.opt.MaxTotalSize-e.written+1)
+	}
+	n, err := io.Copy(f, src)
+	e.written += n
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if e.opt.MaxTotalSize > 0 && e.written > e.opt.MaxTotalSize {
+		_ = os.Remove(p)
+		return ErrSizeLimit
+	}
+	if err := os.Chmod(p, permOr(mode, 0644)); err != nil {
+		return err
+	}
+	return chtimes(p, mtime)
+}
+
+// symlink 登记符号链接，在 finish 中于其余条目之后创建。
+// 此处先按当前状态拒绝明显越界的目标；链接之间可以相互改变解析结果
+// （如 x -> d/.. 与 d -> .），因此 finish 会按最终目录状态再次校验
+func (e *extractor) symlink(p, linkname string) error {
+	if !e.opt.AllowSymlinks {
+		return fmt.Errorf("%w: %s -> %s", ErrSymlink, p, linkname)
+	}
+	if linkname == "" || filepath.IsAbs(linkname) || strings.HasPrefix(linkname, "/") {
+		return fmt.Errorf("%w: %s -> %s", ErrSymlink, p, linkname)
+	}
+	relDir, err := filepath.Rel(e.root, filepath.Dir(p))
+	if err != nil {
+		return err
+	}
+	// 不能先做词法 Clean："a/../x" 在 a 为链接时与 "x" 含义不同
+	if _, ok := e.resolve(filepath.ToSlash(relDir)+"/"+linkname, 0); !ok {
+		return fmt.Errorf("%w: %s -> %s", ErrSymlink, p, linkname)
+	}
+	l := &linkMeta{path: p, target: linkname}
+	e.links = append(e.links, l)
+	e.pending[p] = l
+	return nil
+}
+
+// createLinks 创建全部登记的符号链接，再按最终目录状态逐个校验，
+// 任一链接解析到解压目录之外时删除本次创建的所有链接
+func (e *extractor) createLinks() error {
+	var created []*linkMeta
+	for _, l := range e.links {
+		if l.dropped {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+			return err
+		}
+		if _, err := os.Lstat(l.path); err == nil {
+			if err := os.RemoveAll(l.path); err != nil {
+				return err
+			}
+		}
+		if err := os.Symlink(l.target, l.path); err != nil {
+			return err
+		}
+		created = append(created, l)
+	}
+	for _, l := range created {
+		rel, err := filepath.Rel(e.root, l.path)
+		if err != nil {
+			return err
+		}
+		if _, ok := e.resolve(filepath.ToSlash(rel), 0); !ok {
+			for _, c := range created {
+				_ = os.Remove(c.path)
+			}
+			return fmt.Errorf("%w: %s -> %s", ErrSymlink, l.path, l.target)
+		}
+	}
+	for _, l := range created {
+		if l.owner {
+			if err := lchown(l.path, l.uid, l.gid); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// hardlink 创建硬链接，源必须是解压目录内已存在的普通文件
+func (e *extractor) hardlink(p, linkname string) error {
+	rel, err := cleanEntryName(linkname)
+	if err != nil {
+		return err
+	}
+	old, err := e.join(rel)
+	if err != nil {
+		return err
+	}
+	if _, ok := e.pending[old]; ok {
+		return fmt.Errorf("%w: hardlink %s -> %s", ErrIllegalPath, p, linkname)
+	}
+	fi, err := os.Lstat(old)
+	if err != nil {
+		return err
+	}
+	if !fi.Mode().IsRegular() {
+		return fmt.Errorf("%w: hardlink %s -> %s", ErrIllegalPath, p, linkname)
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	if _, err := os.Lstat(p); err == nil {
+		if err := os.RemoveAll(p); err != nil {
+			return err
+		}
+	}
+	return os.Link(old, p)
+}
+
+// chown 按需还原属主
+func (e *extractor) chown(p string, uid, gid int) error {
+	if !e.opt.PreserveOwner {
+		return nil
+	}
+	if l, ok := e.pending[p]; ok {
+		l.uid, l.gid, l.owner = uid, gid, true
+		return nil
+	}
+	return lchown(p, uid, gid)
+}
+
+// resolve 按文件系统实际状态逐级解析 rel（相对 root，/ 分隔），跟随已存在的链接，
+// 返回解析后的路径分量；任何一步越出 root 即失败
+func (e *extractor) resolve(rel string, depth int) ([]string, bool) {
+	if depth > 40 {
+		return nil, false
+	}
+	var stack []string
+	for _, part := range strings.Split(rel, "/") {
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			if len(stack) == 0 {
+				return nil, false
+			}
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		cur := filepath.Join(e.root, filepath.Join(stack...), part)
+		fi, err := os.Lstat(cur)
+		if err != nil || fi.Mode()&os.ModeSymlink == 0 {
+			stack = append(stack, part)
+			continue
+		}
+		link, err := os.Readlink(cur)
+		if err != nil || filepath.IsAbs(link) {
+			return nil, false
+		}
+		// 链接目标相对其所在目录解析
+		sub, ok := e.resolve(strings.Join(stack, "/")+"/"+filepath.ToSlash(link), depth+1)
+		if !ok {
+			return nil, false
+		}
+		stack = sub
+	}
+	return stack, true
+}
+
+// finish 创建符号链接，并由深到浅设置目录权限与修改时间
+func (e *extractor) finish() error {
+	if err := e.createLinks(); err != nil {
+		return err
+	}
+	sort.SliceStable(e.dirs, func(i, j int) bool {
+		return strings.Count(e.dirs[i].path, string(filepath.Separator)) > strings.Count(e.dirs[j].path, string(filepath.Separator))
+	})
+	for _, d := range e.dirs {
+		if err := os.Chmod(d.path, d.mode); err != nil {
+			return err
+		}
+		if err := chtimes(d.path, d.mtime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func permOr(mode os.FileMode, def os.FileMode) os.FileMode {
+	if mode.Perm() == 0 {
+		return def
+	}
+	return mode.Perm()
+}
+
+func chtimes(p string, mtime time.Time) error {
+	if mtime.IsZero() {
+		return nil
+	}
+	return os.Chtimes(p, mtime, mtime)
+}
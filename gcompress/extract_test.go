@@ -0,0 +1,249 @@
+package gcompress
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// entry 测试归档条目：typ 为 tar 类型标志，link 为链接目标
+type entry struct {
+	name string
+	typ  byte
+	link string
+	body string
+}
+
+func file(name, body string) entry  { return entry{name: name, typ: tar.TypeReg, body: body} }
+func dir(name string) entry         { return entry{name: name, typ: tar.TypeDir} }
+func symlink(name, to string) entry { return entry{name: name, typ: tar.TypeSymlink, link: to} }
+func hardlink(name, to string) entry {
+	return entry{name: name, typ: tar.TypeLink, link: to}
+}
+
+func buildTar(t *testing.T, entries []entry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Typeflag: e.typ, Linkname: e.link, Mode: 0644, Size: int64(len(e.body))}
+		if e.typ == tar.TypeDir {
+			hdr.Mode = 0755
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// buildZip 硬链接在 zip 中没有对应表示，调用方不应传入
+func buildZip(t *testing.T, entries []entry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		hdr := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		body := e.body
+		switch e.typ {
+		case tar.TypeDir:
+			hdr.SetMode(os.ModeDir | 0755)
+		case tar.TypeSymlink:
+			hdr.SetMode(os.ModeSymlink | 0777)
+			body = e.link
+		default:
+			hdr.SetMode(0644)
+		}
+		w, err := zw.CreateHeader(hdr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// sandbox 返回解压目录 root 及其外部放有 secret 文件的父目录
+func sandbox(t *testing.T) (base, root string) {
+	t.Helper()
+	base = t.TempDir()
+	if err := os.WriteFile(filepath.Join(base, "secret"), []byte("outside"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return base, filepath.Join(base, "root")
+}
+
+// assertContained 确认解压目录之外没有新增文件，且经由解压目录读不到外部文件
+func assertContained(t *testing.T, base, root string) {
+	t.Helper()
+	names, err := os.ReadDir(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range names {
+		if n.Name() != "secret" && n.Name() != "root" {
+			t.Errorf("entry written outside root: %s", n.Name())
+		}
+	}
+	filepath.Walk(root, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if b, err := os.ReadFile(p); err == nil && string(b) == "outside" {
+			t.Errorf("%s reads a file outside root", p)
+		}
+		return nil
+	})
+	for _, p := range []string{"x/secret", "d/secret", "l/secret", "l/../secret"} {
+		if b, err := os.ReadFile(filepath.Join(root, p)); err == nil && string(b) == "outside" {
+			t.Errorf("%s reads a file outside root", p)
+		}
+	}
+}
+
+// maliciousCorpus 恶意归档语料，zip 为 false 表示仅适用于 tar（硬链接）
+var maliciousCorpus = []struct {
+	name    string
+	entries []entry
+	opt     ExtractOptions
+	want    error
+	zip     bool
+}{
+	{name: "zip slip", entries: []entry{file("../evil", "x")}, want: ErrIllegalPath, zip: true},
+	{name: "nested zip slip", entries: []entry{file("a/../../evil", "x")}, want: ErrIllegalPath, zip: true},
+	{name: "backslash zip slip", entries: []entry{file(`..\evil`, "x")}, want: ErrIllegalPath, zip: true},
+	{name: "absolute path", entries: []entry{file("/tmp/evil", "x")}, want: ErrIllegalPath, zip: true},
+	{name: "drive letter", entries: []entry{file(`C:\evil`, "x")}, want: ErrIllegalPath, zip: true},
+	{name: "symlink disabled", entries: []entry{symlink("l", "a")}, want: ErrSymlink, zip: true},
+	{
+		name: "absolute symlink", entries: []entry{symlink("l", "/etc")},
+		opt: ExtractOptions{AllowSymlinks: true}, want: ErrSymlink, zip: true,
+	},
+	{
+		name: "parent symlink", entries: []entry{symlink("l", "..")},
+		opt: ExtractOptions{AllowSymlinks: true}, want: ErrSymlink, zip: true,
+	},
+	{
+		name: "symlink escape in subdir", entries: []entry{dir("a"), symlink("a/l", "../../secret")},
+		opt: ExtractOptions{AllowSymlinks: true}, want: ErrSymlink, zip: true,
+	},
+	{
+		name: "write through symlink", entries: []entry{symlink("l", "sub"), file("l/evil", "x")},
+		opt: ExtractOptions{AllowSymlinks: true}, want: ErrSymlink, zip: true,
+	},
+	{
+		name: "chained symlinks", entries: []entry{symlink("x", "d/.."), symlink("d", ".")},
+		opt: ExtractOptions{AllowSymlinks: true}, want: ErrSymlink, zip: true,
+	},
+	{
+		name: "chained symlinks in subdir", entries: []entry{dir("a"), symlink("a/x", "d/../.."), symlink("a/d", ".")},
+		opt: ExtractOptions{AllowSymlinks: true}, want: ErrSymlink, zip: true,
+	},
+	{
+		name: "symlink retargeted by later directory link",
+		entries: []entry{dir("a"), dir("a/b"), symlink("x", "a/b/../.."), symlink("a", "."),
+			symlink("b", ".")},
+		opt: ExtractOptions{AllowSymlinks: true}, want: ErrSymlink, zip: true,
+	},
+	{
+		name: "symlink loop", entries: []entry{symlink("a", "b"), symlink("b", "a")},
+		opt: ExtractOptions{AllowSymlinks: true}, want: ErrSymlink, zip: true,
+	},
+	{name: "hardlink escape", entries: []entry{hardlink("h", "../secret")}, want: ErrIllegalPath},
+	{name: "hardlink absolute", entries: []entry{hardlink("h", "/etc/passwd")}, want: ErrIllegalPath},
+	{
+		name: "hardlink through symlink", entries: []entry{symlink("l", "."), hardlink("h", "l/f")},
+		opt: ExtractOptions{AllowSymlinks: true}, want: ErrSymlink,
+	},
+	{
+		name: "hardlink to symlink", entries: []entry{file("f", "x"), symlink("l", "f"), hardlink("h", "l")},
+		opt: ExtractOptions{AllowSymlinks: true}, want: ErrIllegalPath,
+	},
+	{
+		name:    "size bomb",
+		entries: []entry{file("a", strings.Repeat("a", 600)), file("b", strings.Repeat("b", 600))},
+		opt:     ExtractOptions{MaxTotalSize: 1000}, want: ErrSizeLimit, zip: true,
+	},
+	{
+		name: "too many entries", entries: []entry{file("a", ""), file("b", ""), file("c", "")},
+		opt: ExtractOptions{MaxEntries: 2}, want: ErrTooManyEntries, zip: true,
+	},
+}
+
+func TestExtractMaliciousTar(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require unix")
+	}
+	for _, tc := range maliciousCorpus {
+		t.Run(tc.name, func(t *testing.T) {
+			base, root := sandbox(t)
+			err := UntarReader(bytes.NewReader(buildTar(t, tc.entries)), root, tc.opt)
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("got %v, want %v", err, tc.want)
+			}
+			assertContained(t, base, root)
+		})
+	}
+}
+
+func TestExtractMaliciousZip(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require unix")
+	}
+	for _, tc := range maliciousCorpus {
+		if !tc.zip {
+			continue
+		}
+		t.Run(tc.name, func(t *testing.T) {
+			base, root := sandbox(t)
+			data := buildZip(t, tc.entries)
+			err := UnzipReader(bytes.NewReader(data), int64(len(data)), root, tc.opt)
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("got %v, want %v", err, tc.want)
+			}
+			assertContained(t, base, root)
+		})
+	}
+}
+
+func TestExtractSafeSymlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require unix")
+	}
+	entries := []entry{
+		dir("lib"), file("lib/real.so", "so"),
+		symlink("lib/cur.so", "real.so"),
+		dir("bin"), symlink("bin/lib", "../lib"),
+		symlink("self", "."),
+		// 后出现的普通文件覆盖同名链接
+		symlink("over", "lib"), file("over", "file"),
+	}
+	base, root := sandbox(t)
+	if err := UntarReader(bytes.NewReader(buildTar(t, entries)), root, ExtractOptions{AllowSymlinks: true}); err != nil {
+		t.Fatal(err)
+	}
+	for p, want := range map[string]string{"lib/cur.so": "so", "bin/lib/real.so": "so", "self/lib/real.so": "so", "over": "file"} {
+		b, err := os.ReadFile(filepath.Join(root, p))
+		if err != nil || string(b) != want {
+			t.Errorf("%s = %q, %v; want %q", p, b, err, want)
+		}
+	}
+	assertContained(t, base, root)
+}
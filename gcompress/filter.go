@@ -0,0 +1,115 @@
+package gcompress
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// filter include / exclude 过滤器
+// 模式不含 / 时匹配任意一级的名称（如 "*.log"），含 / 时匹配完整相对路径（如 "logs/**/*.gz"）
+// ** 匹配任意层目录
+type filter struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+func newFilter(include, exclude []string) (*filter, error) {
+	f := &filter{}
+	for _, p := range include {
+		re, err := globRegexp(p)
+		if err != nil {
+			return nil, err
+		}
+		f.include = append(f.include, re)
+	}
+	for _, p := range exclude {
+		re, err := globRegexp(p)
+		if err != nil {
+			return nil, err
+		}
+		f.exclude = append(f.exclude, re)
+	}
+	return f, nil
+}
+
+// excluded 路径是否被排除（目录被排除时其下内容一并排除）
+func (f *filter) excluded(rel string) bool {
+	rel = strings.Trim(rel, "/")
+	for _, re := range f.exclude {
+		if matchAny(re, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// included 文件是否满足 include（未配置 include 时全部满足）
+func (f *filter) included(rel string) bool {
+	if len(f.include) == 0 {
+		return true
+	}
+	rel = strings.Trim(rel, "/")
+	for _, re := range f.include {
+		if matchAny(re, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// keep 文件是否保留
+func (f *filter) keep(rel string) bool {
+	return !f.excluded(rel) && f.included(rel)
+}
+
+// matchAny 依次匹配 rel 本身、各级父目录与各级名称
+func matchAny(re *regexp.Regexp, rel string) bool {
+	for p := rel; p != "." && p != ""; p = path.Dir(p) {
+		if re.MatchString(p) || re.MatchString(path.Base(p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// globRegexp 将 glob 转为正则
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	p := strings.Trim(strings.ReplaceAll(pattern, "\\", "/"), "/")
+	if _, err := path.Match(strings.ReplaceAll(p, "**", "*"), ""); err != nil {
+		return nil, err
+	}
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		switch c {
+		case '*':
+			if i+1 < len(p) && p[i+1] == '*' {
+				i++
+				if i+1 < len(p) && p[i+1] == '/' {
+					i++
+					sb.WriteString("(?:.*/)?")
+				} else {
+					sb.WriteString(".*")
+				}
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			j := strings.IndexByte(p[i:], ']')
+			cls := p[i+1 : i+j]
+			if strings.HasPrefix(cls, "!") {
+				cls = "^" + cls[1:]
+			}
+			sb.WriteString("[" + cls + "]")
+			i += j
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
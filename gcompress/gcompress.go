@@ -1,9 +1,7 @@
 package gcompress
 
 import (
-	"archive/zip"
 	"bytes"
-	"compress/gzip"
 	"io"
 	"os"
 	"path/filepath"
@@ -11,8 +9,21 @@ import (
 
 // GzipBytes gzip 压缩字节
 func GzipBytes(data []byte) ([]byte, error) {
+	return CompressBytes(data, CompressGzip, 0)
+}
+
+// GunzipBytes gzip 解压
+func GunzipBytes(data []byte) ([]byte, error) {
+	return DecompressBytes(data, CompressGzip)
+}
+
+// CompressBytes 以指定格式压缩字节，level 为 0 表示默认级别
+func CompressBytes(data []byte, name Compression, level int) ([]byte, error) {
 	var buf bytes.Buffer
-	w := gzip.NewWriter(&buf)
+	w, err := NewWriter(&buf, name, level)
+	if err != nil {
+		return nil, err
+	}
 	if _, err := w.Write(data); err != nil {
 		return nil, err
 	}
@@ -22,56 +33,26 @@ func GzipBytes(data []byte) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-// GunzipBytes gzip 解压
-func GunzipBytes(data []byte) ([]byte, error) {
-	r, err := gzip.NewReader(bytes.NewReader(data))
+// DecompressBytes 解压字节，未指定格式时按数据头自动识别
+func DecompressBytes(data []byte, name ...Compression) ([]byte, error) {
+	r, err := NewReader(bytes.NewReader(data), name...)
 	if err != nil {
 		return nil, err
 	}
+	defer r.Close()
 	return io.ReadAll(r)
 }
 
-// ZipFile 压缩单个文件或目录
-func ZipFile(src, dst string) error {
-	f, err := os.Create(dst)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-	w := zip.NewWriter(f)
-	defer w.Close()
+// ZipFile 压缩单个文件或目录，目录内容直接位于压缩包根
+// 保留权限、修改时间与空目录，更多行为见 ZipOptions
+func ZipFile(src, dst string, opt ...ZipOptions) error {
 	info, err := os.Stat(src)
 	if err != nil {
 		return err
 	}
-	if info.IsDir() {
-		return filepath.Walk(src, func(path string, fi os.FileInfo, err error) error {
-			if err != nil || fi.IsDir() {
-				return err
-			}
-			rel, _ := filepath.Rel(src, path)
-			fw, err := w.Create(rel)
-			if err != nil {
-				return err
-			}
-			file, err := os.Open(path)
-			if err != nil {
-				return err
-			}
-			defer file.Close()
-			_, err = io.Copy(fw, file)
-			return err
-		})
-	}
-	fw, err := w.Create(filepath.Base(src))
-	if err != nil {
-		return err
-	}
-	file, err := os.Open(src)
-	if err != nil {
-		return err
+	root := zipRoot{path: src}
+	if !info.IsDir() {
+		root.name = filepath.Base(src)
 	}
-	defer file.Close()
-	_, err = io.Copy(fw, file)
-	return err
+	return zipRoots([]zipRoot{root}, dst, opt...)
 }
@@ -0,0 +1,584 @@
+package gcompress
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/hellobchain/gotool/gprogress"
+)
+
+// ProgressFunc 进度回调：done 为已处理的未压缩字节数，total 为总字节数（未知时为 -1）
+type ProgressFunc func(done, total int64)
+
+// BarProgress 用 gprogress.Bar 显示进度
+func BarProgress(b *gprogress.Bar) ProgressFunc {
+	return func(done, total int64) {
+		if total > 0 {
+			b.SetTotal(total)
+		}
+		b.Set(done)
+	}
+}
+
+// ParallelOptions 并行压缩选项
+type ParallelOptions struct {
+	// 压缩级别，0 表示默认
+	Level int
+	// 并发数，<=0 表示 CPU 核数
+	Workers int
+	// gzip 分块大小，<=0 表示 1MB
+	BlockSize int
+	// 进度回调，可为 nil
+	Progress ProgressFunc
+}
+
+func (o ParallelOptions) normalize() ParallelOptions {
+	if o.Level == 0 {
+		o.Level = flate.DefaultCompression
+	}
+	if o.Workers <= 0 {
+		o.Workers = runtime.NumCPU()
+	}
+	if o.BlockSize <= 0 {
+		o.BlockSize = 1 << 20
+	}
+	return o
+}
+
+// progress 串行化进度回调
+type progress struct {
+	mu    sync.Mutex
+	fn    ProgressFunc
+	done  int64
+	total int64
+}
+
+func (p *progress) add(n int64) {
+	if p.fn == nil || n == 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done += n
+	p.fn(p.done, p.total)
+}
+
+// ---------------- 并行 gzip ----------------
+
+// dictSize deflate 滑动窗口大小，每块以前一块末尾 32KB 作为字典
+const dictSize = 32 << 10
+
+// ParallelGzipWriter pigz 式并行 gzip 写入器
+// 数据按块切分后并行压缩，按顺序拼接为单个 deflate 流，输出仍是标准 gzip
+type ParallelGzipWriter struct {
+	w        io.Writer
+	opt      ParallelOptions
+	buf      []byte
+	dict     []byte
+	crc      uint32
+	size     int64
+	queue    chan chan gzBlock // 按输入顺序排队的压缩结果
+	sem      chan struct{}
+	done     chan struct{}
+	mu       sync.Mutex
+	err      error
+	closed   bool
+	progress *progress
+}
+
+type gzBlock struct {
+	data []byte
+	err  error
+}
+
+// NewParallelGzipWriter 创建并行 gzip 写入器，Close 后才写出完整数据
+func NewParallelGzipWriter(w io.Writer, opt ...ParallelOptions) *ParallelGzipWriter {
+	option := ParallelOptions{}
+	if len(opt) > 0 {
+		option = opt[0]
+	}
+	return newParallelGzipWriter(w, option, -1)
+}
+
+func newParallelGzipWriter(w io.Writer, opt ParallelOptions, total int64) *ParallelGzipWriter {
+	opt = opt.normalize()
+	z := &ParallelGzipWriter{
+		w:        w,
+		opt:      opt,
+		queue:    make(chan chan gzBlock, opt.Workers),
+		sem:      make(chan struct{}, opt.Workers),
+		done:     make(chan struct{}),
+		progress: &progress{fn: opt.Progress, total: total},
+	}
+	go z.loop()
+	return z
+}
+
+// loop 按顺序写出头部与各块压缩结果
+func (z *ParallelGzipWriter) loop() {
+	defer close(z.done)
+	// 头部：魔数、deflate、无标志、mtime=0、未知 OS
+	if _, err := z.w.Write([]byte{0x1f, 0x8b, 8, 0, 0, 0, 0, 0, 0, 255}); err != nil {
+		z.setErr(err)
+	}
+	for ch := range z.queue {
+		b := <-ch
+		if z.getErr() != nil {
+			continue
+		}
+		if b.err != nil {
+			z.setErr(b.err)
+			continue
+		}
+		if _, err := z.w.Write(b.data); err != nil {
+			z.setErr(err)
+		}
+	}
+}
+
+func (z *ParallelGzipWriter) setErr(err error) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	if z.err == nil {
+		z.err = err
+	}
+}
+
+func (z *ParallelGzipWriter) getErr() error {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	return z.err
+}
+
+// Write 写入未压缩数据
+func (z *ParallelGzipWriter) Write(p []byte) (int, error) {
+	if z.closed {
+		return 0, os.ErrClosed
+	}
+	if err := z.getErr(); err != nil {
+		return 0, err
+	}
+	z.crc = crc32.Update(z.crc, crc32.IEEETable, p)
+	z.size += int64(len(p))
+	n := len(p)
+	for len(p) > 0 {
+		if z.buf == nil {
+			z.buf = make([]byte, 0, z.opt.BlockSize)
+		}
+		k := min(z.opt.BlockSize-len(z.buf), len(p))
+		z.buf = append(z.buf, p[:k]...)
+		p = p[k:]
+		if len(z.buf) == z.opt.BlockSize {
+			z.dispatch(z.buf, false)
+			z.buf = nil
+		}
+	}
+	z.progress.add(int64(n))
+	return n, nil
+}
+
+// dispatch 提交一个块并行压缩，队列满时阻塞以限制内存
+func (z *ParallelGzipWriter) dispatch(block []byte, last bool) {
+	ch := make(chan gzBlock, 1)
+	z.queue <- ch
+	z.sem <- struct{}{}
+	go func(dict []byte) {
+		defer func() { <-z.sem }()
+		ch <- compressBlock(block, dict, z.opt.Level, last)
+	}(z.dict)
+	if len(block) >= dictSize {
+		z.dict = block[len(block)-dictSize:]
+	} else {
+		d := append(append([]byte{}, z.dict...), block...)
+		z.dict = d[max(0, len(d)-dictSize):]
+	}
+}
+
+// compressBlock 压缩单块：非末块以 sync flush 结尾保证字节对齐，末块写入结束标记
+func compressBlock(block, dict []byte, level int, last bool) gzBlock {
+	var out bytes.Buffer
+	fw, err := flate.NewWriterDict(&out, level, dict)
+	if err != nil {
+		return gzBlock{err: err}
+	}
+	if _, err := fw.Write(block); err != nil {
+		return gzBlock{err: err}
+	}
+	if last {
+		err = fw.Close()
+	} else {
+		err = fw.Flush()
+	}
+	return gzBlock{data: out.Bytes(), err: err}
+}
+
+// Close 压缩剩余数据并写出尾部，不关闭底层 w
+func (z *ParallelGzipWriter) Close() error {
+	if z.closed {
+		return z.getErr()
+	}
+	z.closed = true
+	z.dispatch(z.buf, true)
+	z.buf = nil
+	close(z.queue)
+	<-z.done
+	if err := z.getErr(); err != nil {
+		return err
+	}
+	var trailer [8]byte
+	binary.LittleEndian.PutUint32(trailer[:4], z.crc)
+	binary.LittleEndian.PutUint32(trailer[4:], uint32(z.size))
+	_, err := z.w.Write(trailer[:])
+	return err
+}
+
+// ParallelGzipFile 并行 gzip 压缩文件，ctx 取消或出错时删除不完整的输出文件
+func ParallelGzipFile(ctx context.Context, src, dst string, opt ...ParallelOptions) (err error) {
+	option := ParallelOptions{}
+	if len(opt) > 0 {
+		option = opt[0]
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			_ = os.Remove(dst)
+		}
+	}()
+	z := newParallelGzipWriter(out, option, info.Size())
+	_, err = io.Copy(z, ctxReader{ctx, in})
+	if cerr := z.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// ctxReader 每次读取前检查 ctx
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c ctxReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// ---------------- 并行 zip ----------------
+
+// spoolThreshold 压缩结果超过该大小时写入临时文件，避免大文件占用内存
+const spoolThreshold = 4 << 20
+
+type zipJob struct {
+	path string
+	hdr  *zip.FileHeader
+	info os.FileInfo
+}
+
+type zipResult struct {
+	hdr   *zip.FileHeader
+	spool *spool
+	err   error
+}
+
+// ParallelZip 并发压缩目录：各条目在 worker 中 deflate，按遍历顺序写入 zip
+// ctx 取消或出错时删除不完整的输出文件
+func ParallelZip(ctx context.Context, src, dst string, opt ...ParallelOptions) (err error) {
+	option := ParallelOptions{}
+	if len(opt) > 0 {
+		option = opt[0]
+	}
+	option = option.normalize()
+	jobs, total, err := collectZipJobs(src)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			_ = os.Remove(dst)
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	prog := &progress{fn: option.Progress, total: total}
+	results := make([]chan zipResult, len(jobs))
+	for i := range results {
+		results[i] = make(chan zipResult, 1)
+	}
+	// sem 由写入方释放，保证同时在途（已压缩未写出）的条目不超过 Workers 个
+	sem := make(chan struct{}, option.Workers)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i, job := range jobs {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			wg.Add(1)
+			go func(i int, job zipJob) {
+				defer wg.Done()
+				results[i] <- compressZipJob(ctx, job, option.Level, prog)
+			}(i, job)
+		}
+	}()
+	defer func() {
+		// 取消后等待 worker 退出并清理未写出的临时数据
+		cancel()
+		wg.Wait()
+		for _, ch := range results {
+			select {
+			case r := <-ch:
+				r.spool.Close()
+			default:
+			}
+		}
+	}()
+
+	w := zip.NewWriter(f)
+	for i := range jobs {
+		var r zipResult
+		select {
+		case r = <-results[i]:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		err := writeZipResult(w, r)
+		<-sem
+		if err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// collectZipJobs 遍历目录，返回条目与普通文件总字节数
+func collectZipJobs(src string) ([]zipJob, int64, error) {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return nil, 0, err
+	}
+	var jobs []zipJob
+	var total int64
+	add := func(path, name string, fi os.FileInfo) error {
+		hdr, err := zip.FileInfoHeader(fi)
+		if err != nil {
+			return err
+		}
+		hdr.Name = name
+		if fi.IsDir() {
+			hdr.Name += "/"
+		}
+		if fi.Mode().IsRegular() {
+			total += fi.Size()
+		}
+		jobs = append(jobs, zipJob{path: path, hdr: hdr, info: fi})
+		return nil
+	}
+	if !info.IsDir() {
+		err = add(src, filepath.Base(src), info)
+		return jobs, total, err
+	}
+	err = filepath.Walk(src, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		return add(path, filepath.ToSlash(rel), fi)
+	})
+	return jobs, total, err
+}
+
+// compressZipJob 在 worker 中压缩单个条目
+func compressZipJob(ctx context.Context, job zipJob, level int, prog *progress) zipResult {
+	hdr := job.hdr
+	mode := job.info.Mode()
+	switch {
+	case mode.IsDir():
+		hdr.Method = zip.Store
+		hdr.CompressedSize64, hdr.UncompressedSize64 = 0, 0
+		return zipResult{hdr: hdr}
+	case mode&os.ModeSymlink != 0:
+		link, err := os.Readlink(job.path)
+		if err != nil {
+			return zipResult{err: err}
+		}
+		hdr.Method = zip.Store
+		hdr.CRC32 = crc32.ChecksumIEEE([]byte(link))
+		hdr.CompressedSize64 = uint64(len(link))
+		hdr.UncompressedSize64 = uint64(len(link))
+		sp := &spool{}
+		sp.buf.WriteString(link)
+		return zipResult{hdr: hdr, spool: sp}
+	case !mode.IsRegular():
+		return zipResult{}
+	}
+	in, err := os.Open(job.path)
+	if err != nil {
+		return zipResult{err: err}
+	}
+	defer in.Close()
+	sp := &spool{}
+	fw, err := flate.NewWriter(sp, level)
+	if err != nil {
+		return zipResult{err: err}
+	}
+	h := crc32.NewIEEE()
+	buf := make([]byte, 256<<10)
+	var n int64
+	for {
+		if err := ctx.Err(); err != nil {
+			sp.Close()
+			return zipResult{err: err}
+		}
+		k, rerr := in.Read(buf)
+		if k > 0 {
+			h.Write(buf[:k])
+			if _, err := fw.Write(buf[:k]); err != nil {
+				sp.Close()
+				return zipResult{err: err}
+			}
+			n += int64(k)
+			prog.add(int64(k))
+		}
+		if rerr == io.EOF {
+			break
+		}
+		if rerr != nil {
+			sp.Close()
+			return zipResult{err: rerr}
+		}
+	}
+	if err := fw.Close(); err != nil {
+		sp.Close()
+		return zipResult{err: err}
+	}
+	hdr.Method = zip.Deflate
+	hdr.CRC32 = h.Sum32()
+	hdr.UncompressedSize64 = uint64(n)
+	hdr.CompressedSize64 = uint64(sp.size)
+	return zipResult{hdr: hdr, spool: sp}
+}
+
+func writeZipResult(w *zip.Writer, r zipResult) error {
+	if r.err != nil {
+		r.spool.Close()
+		return r.err
+	}
+	if r.hdr == nil {
+		return nil
+	}
+	defer r.spool.Close()
+	setRawModTime(r.hdr)
+	fw, err := w.CreateRaw(r.hdr)
+	if err != nil {
+		return err
+	}
+	if r.spool == nil {
+		return nil
+	}
+	rd, err := r.spool.reader()
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(fw, rd)
+	return err
+}
+
+// setRawModTime CreateRaw 不处理 Modified 字段，需要手动写入 MS-DOS 时间
+func setRawModTime(hdr *zip.FileHeader) {
+	t := hdr.Modified
+	if t.IsZero() {
+		return
+	}
+	if t.Year() < 1980 {
+		hdr.ModifiedDate, hdr.ModifiedTime = 1<<5|1, 0
+		return
+	}
+	hdr.ModifiedDate = uint16(t.Day() + int(t.Month())<<5 + (t.Year()-1980)<<9)
+	hdr.ModifiedTime = uint16(t.Second()/2 + t.Minute()<<5 + t.Hour()<<11)
+}
+
+// spool 先写内存，超过阈值后转存临时文件
+type spool struct {
+	buf  bytes.Buffer
+	file *os.File
+	size int64
+}
+
+func (s *spool) Write(p []byte) (int, error) {
+	s.size += int64(len(p))
+	if s.file == nil && s.buf.Len()+len(p) > spoolThreshold {
+		f, err := os.CreateTemp("", "gcompress-*")
+		if err != nil {
+			return 0, err
+		}
+		s.file = f
+		if _, err := f.Write(s.buf.Bytes()); err != nil {
+			return 0, err
+		}
+		s.buf = bytes.Buffer{}
+	}
+	if s.file != nil {
+		return s.file.Write(p)
+	}
+	return s.buf.Write(p)
+}
+
+func (s *spool) reader() (io.Reader, error) {
+	if s.file == nil {
+		return &s.buf, nil
+	}
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return s.file, nil
+}
+
+// Close 释放临时文件，可重复调用，nil 安全
+func (s *spool) Close() {
+	if s == nil || s.file == nil {
+		return
+	}
+	s.file.Close()
+	os.Remove(s.file.Name())
+	s.file = nil
+}
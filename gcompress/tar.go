@@ -0,0 +1,288 @@
+package gcompress
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TarOptions 打包选项
+type TarOptions struct {
+	// 压缩格式，为空时按目标文件后缀推断（.tar.gz/.tgz、.tar.zst/.tzst、.tar.lz4 等）
+	Compression Compression
+	// 压缩级别，0 表示默认
+	Level int
+	// 仅打包匹配的文件（glob，空表示全部）
+	Include []string
+	// 跳过匹配的文件或目录（glob）
+	Exclude []string
+	// 记录属主 uid/gid 与用户名，否则统一为 0
+	PreserveOwner bool
+}
+
+// CompressionFromExt 根据文件名后缀推断压缩格式
+func CompressionFromExt(name string) Compression {
+	n := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(n, ".gz"), strings.HasSuffix(n, ".tgz"):
+		return CompressGzip
+	case strings.HasSuffix(n, ".zst"), strings.HasSuffix(n, ".tzst"):
+		return CompressZstd
+	case strings.HasSuffix(n, ".lz4"):
+		return CompressLZ4
+	case strings.HasSuffix(n, ".sz"), strings.HasSuffix(n, ".snappy"):
+		return CompressSnappy
+	case strings.HasSuffix(n, ".zz"), strings.HasSuffix(n, ".zlib"):
+		return CompressZlib
+	case strings.HasSuffix(n, ".br"):
+		return CompressBrotli
+	}
+	return CompressNone
+}
+
+// TarDir 将文件或目录打包为 tar / tar.gz / tar.zst 等
+// 保留权限、修改时间、符号链接与硬链接；出错时删除不完整的输出文件
+func TarDir(src, dst string, opt ...TarOptions) (err error) {
+	option := TarOptions{}
+	if len(opt) > 0 {
+		option = opt[0]
+	}
+	if option.Compression == CompressNone {
+		option.Compression = CompressionFromExt(dst)
+	}
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			_ = os.Remove(dst)
+		}
+	}()
+	return WriteTar(f, src, option)
+}
+
+// WriteTar 将文件或目录以 tar 流写入 w，按 opt.Compression 压缩
+func WriteTar(w io.Writer, src string, opt ...TarOptions) error {
+	option := TarOptions{}
+	if len(opt) > 0 {
+		option = opt[0]
+	}
+	flt, err := newFilter(option.Include, option.Exclude)
+	if err != nil {
+		return err
+	}
+	cw, err := NewWriter(w, option.Compression, option.Level)
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(cw)
+	if err := writeTarEntries(tw, src, flt, option); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return cw.Close()
+}
+
+func writeTarEntries(tw *tar.Writer, src string, flt *filter, opt TarOptions) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	links := make(map[[2]uint64]string)
+	if !info.IsDir() {
+		return writeTarEntry(tw, src, filepath.Base(src), info, links, opt)
+	}
+	return filepath.Walk(src, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if flt.excluded(rel) {
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// 配置了 include 时目录本身不匹配也要继续遍历，只是不写目录条目
+		if !flt.included(rel) {
+			return nil
+		}
+		return writeTarEntry(tw, path, rel, fi, links, opt)
+	})
+}
+
+func writeTarEntry(tw *tar.Writer, path, name string, fi os.FileInfo, links map[[2]uint64]string, opt TarOptions) error {
+	link := ""
+	if fi.Mode()&os.ModeSymlink != 0 {
+		l, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		link = l
+	}
+	hdr, err := tar.FileInfoHeader(fi, link)
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	if fi.IsDir() {
+		hdr.Name += "/"
+	}
+	if !opt.PreserveOwner {
+		hdr.Uid, hdr.Gid, hdr.Uname, hdr.Gname = 0, 0, "", ""
+	}
+	if fi.Mode().IsRegular() {
+		if key, ok := inodeKey(fi); ok {
+			if first, seen := links[key]; seen {
+				hdr.Typeflag = tar.TypeLink
+				hdr.Linkname = first
+				hdr.Size = 0
+				return tw.WriteHeader(hdr)
+			}
+			links[key] = name
+		}
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if !fi.Mode().IsRegular() {
+		return nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.CopyN(tw, file, hdr.Size)
+	return err
+}
+
+// Untar 解压 tar / tar.gz / tar.zst 到 dst 目录，压缩格式按文件头自动识别
+func Untar(src, dst string, opt ...ExtractOptions) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return UntarReader(f, dst, opt...)
+}
+
+// detectTar 识别 tar 流的压缩格式。数据头本身是校验和正确的 tar 头时视为未压缩，
+// 否则 zlib 这类只有 2 字节弱特征的格式会把名称以 "hb"、"x^" 等开头的条目误判为压缩数据
+func detectTar(r io.Reader) (Compression, io.Reader, error) {
+	br := bufio.NewReader(r)
+	if head, _ := br.Peek(512); isTarHeader(head) {
+		return CompressNone, br, nil
+	}
+	return Detect(br)
+}
+
+// isTarHeader block 为全零块（空归档）或校验和正确的 tar 头
+func isTarHeader(block []byte) bool {
+	if len(block) < 512 {
+		return false
+	}
+	block = block[:512]
+	field := bytes.Trim(block[148:156], " \x00")
+	if len(field) == 0 {
+		return bytes.Count(block, []byte{0}) == 512
+	}
+	var want int64
+	for _, c := range field {
+		if c < '0' || c > '7' {
+			return false
+		}
+		want = want*8 + int64(c-'0')
+	}
+	// 校验和按校验和字段为 8 个空格计算，历史实现有按有符号字节求和的
+	var unsigned, signed int64
+	for i, c := range block {
+		if i >= 148 && i < 156 {
+			c = ' '
+		}
+		unsigned += int64(c)
+		signed += int64(int8(c))
+	}
+	return want == unsigned || want == signed
+}
+
+// UntarReader 从 r 读取 tar 流解压到 dst 目录，压缩格式按文件头自动识别
+// 与 Unzip 相同，拒绝越界路径并按 opt 限制条目数与总大小
+func UntarReader(r io.Reader, dst string, opt ...ExtractOptions) error {
+	option := DefaultExtractOptions
+	if len(opt) > 0 {
+		option = opt[0]
+	}
+	c, br, err := detectTar(r)
+	if err != nil {
+		return err
+	}
+	dr, err := NewReader(br, c)
+	if err != nil {
+		return err
+	}
+	defer dr.Close()
+	ex, err := newExtractor(dst, option)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(dr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := ex.countEntry(); err != nil {
+			return err
+		}
+		if err := untarEntry(ex, tr, hdr); err != nil {
+			return err
+		}
+	}
+	return ex.finish()
+}
+
+func untarEntry(ex *extractor, tr *tar.Reader, hdr *tar.Header) error {
+	p, err := ex.target(hdr.Name)
+	if err != nil || p == "" {
+		return err
+	}
+	mode := hdr.FileInfo().Mode()
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		err = ex.mkdir(p, mode, hdr.ModTime)
+	case tar.TypeReg, tar.TypeRegA:
+		if err := ex.checkDeclared(hdr.Size); err != nil {
+			return err
+		}
+		err = ex.writeFile(p, tr, mode, hdr.ModTime)
+	case tar.TypeSymlink:
+		err = ex.symlink(p, hdr.Linkname)
+	case tar.TypeLink:
+		err = ex.hardlink(p, hdr.Linkname)
+	default:
+		// 设备、管道等特殊文件一律忽略
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return ex.chown(p, hdr.Uid, hdr.Gid)
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package gcompress
+
+import "os"
+
+func inodeKey(fi os.FileInfo) ([2]uint64, bool) { return [2]uint64{}, false }
+
+func lchown(p string, uid, gid int) error { return nil }
@@ -0,0 +1,68 @@
+package gcompress
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 首个条目名恰好满足 zlib CMF/FLG 校验的未压缩 tar 不应被识别为 zlib
+func TestUntarPlainTarWithZlibLikeName(t *testing.T) {
+	for _, name := range []string{"hbase/conf.xml", "Xf", "x^y", "(r.txt", "8n", "Hj/a"} {
+		if !zlibCodecMatches(name) {
+			t.Fatalf("%q does not look like zlib, test is ineffective", name)
+		}
+		data := buildTar(t, []entry{file(name, "payload")})
+		dst := t.TempDir()
+		if err := UntarReader(bytes.NewReader(data), dst); err != nil {
+			t.Fatalf("%q: %v", name, err)
+		}
+		if b, err := os.ReadFile(filepath.Join(dst, name)); err != nil || string(b) != "payload" {
+			t.Fatalf("%q: read back %q, %v", name, b, err)
+		}
+	}
+}
+
+func zlibCodecMatches(name string) bool {
+	c, err := GetCodec(CompressZlib)
+	return err == nil && c.Match([]byte(name))
+}
+
+func TestUntarDetectsCompression(t *testing.T) {
+	plain := buildTar(t, []entry{dir("hbase"), file("hbase/a.txt", "A")})
+	for _, c := range []Compression{CompressNone, CompressGzip, CompressZlib, CompressZstd, CompressLZ4, CompressSnappy} {
+		var buf bytes.Buffer
+		w, err := NewWriter(&buf, c, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(plain)
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		dst := t.TempDir()
+		if err := UntarReader(&buf, dst); err != nil {
+			t.Fatalf("%q: %v", c, err)
+		}
+		if b, err := os.ReadFile(filepath.Join(dst, "hbase", "a.txt")); err != nil || string(b) != "A" {
+			t.Fatalf("%q: read back %q, %v", c, b, err)
+		}
+	}
+	// 空归档只有两个全零块
+	if err := UntarReader(bytes.NewReader(buildTar(t, nil)), t.TempDir()); err != nil {
+		t.Fatalf("empty archive: %v", err)
+	}
+}
+
+func TestIsTarHeader(t *testing.T) {
+	block := buildTar(t, []entry{file("hbase", "x")})[:512]
+	if !isTarHeader(block) {
+		t.Fatal("valid header rejected")
+	}
+	bad := append([]byte(nil), block...)
+	bad[0] ^= 1
+	if isTarHeader(bad) || isTarHeader(block[:511]) {
+		t.Fatal("corrupted header accepted")
+	}
+}
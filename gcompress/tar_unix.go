@@ -0,0 +1,27 @@
+//go:build unix
+
+package gcompress
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// inodeKey 返回多链接文件的 (dev, ino)，用于识别硬链接
+func inodeKey(fi os.FileInfo) ([2]uint64, bool) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok || st.Nlink <= 1 {
+		return [2]uint64{}, false
+	}
+	return [2]uint64{uint64(st.Dev), uint64(st.Ino)}, true
+}
+
+// lchown 修改属主，非 root 用户无权限时忽略
+func lchown(p string, uid, gid int) error {
+	err := os.Lchown(p, uid, gid)
+	if errors.Is(err, os.ErrPermission) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,94 @@
+package gcompress
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+)
+
+// Unzip 解压 zip 文件到 dst 目录
+// 拒绝越界（Zip Slip）与绝对路径条目，保留文件权限与修改时间
+func Unzip(src, dst string, opt ...ExtractOptions) error {
+	zr, err := zip.OpenReader(src)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	return unzip(&zr.Reader, dst, opt...)
+}
+
+// UnzipReader 从 io.ReaderAt 解压 zip 到 dst 目录，size 为数据总长度
+func UnzipReader(r io.ReaderAt, size int64, dst string, opt ...ExtractOptions) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+	return unzip(zr, dst, opt...)
+}
+
+func unzip(zr *zip.Reader, dst string, opt ...ExtractOptions) error {
+	option := DefaultExtractOptions
+	if len(opt) > 0 {
+		option = opt[0]
+	}
+	if option.MaxEntries > 0 && len(zr.File) > option.MaxEntries {
+		return ErrTooManyEntries
+	}
+	ex, err := newExtractor(dst, option)
+	if err != nil {
+		return err
+	}
+	for _, f := range zr.File {
+		if err := ex.countEntry(); err != nil {
+			return err
+		}
+		if err := unzipEntry(ex, f); err != nil {
+			return err
+		}
+	}
+	return ex.finish()
+}
+
+func unzipEntry(ex *extractor, f *zip.File) error {
+	p, err := ex.target(f.Name)
+	if err != nil || p == "" {
+		return err
+	}
+	mode := f.Mode()
+	switch {
+	case mode.IsDir():
+		return ex.mkdir(p, mode, f.Modified)
+	case mode&os.ModeSymlink != 0:
+		link, err := readZipLink(f)
+		if err != nil {
+			return err
+		}
+		return ex.symlink(p, link)
+	case !mode.IsRegular():
+		// 设备、管道等特殊文件一律忽略
+		return nil
+	}
+	if err := ex.checkDeclared(int64(f.UncompressedSize64)); err != nil {
+		return err
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return ex.writeFile(p, rc, mode, f.Modified)
+}
+
+// readZipLink 读取链接条目内容（即链接目标），限制长度防止异常条目
+func readZipLink(f *zip.File) (string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	b, err := io.ReadAll(io.LimitReader(rc, 4096))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
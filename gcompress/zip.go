@@ -0,0 +1,272 @@
+package gcompress
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultStoreExts 已压缩格式，默认以 Store 方式存储，避免重复压缩
+var DefaultStoreExts = []string{
+	".zip", ".gz", ".tgz", ".bz2", ".xz", ".zst", ".lz4", ".br", ".7z", ".rar", ".jar", ".apk",
+	".jpg", ".jpeg", ".png", ".gif", ".webp", ".mp3", ".mp4", ".mkv", ".avi", ".mov",
+	".docx", ".xlsx", ".pptx",
+}
+
+// ZipOptions zip 打包选项
+type ZipOptions struct {
+	// 全部以 Store 方式存储（不压缩）
+	Store bool
+	// 以 Store 方式存储的扩展名，nil 时使用 DefaultStoreExts
+	StoreExts []string
+	// deflate 压缩级别，0 表示默认
+	Level int
+	// 符号链接保存为链接条目，否则保存链接指向的文件内容（指向目录的链接跳过）
+	Symlinks bool
+	// 压缩包注释
+	Comment string
+	// 条目名前缀，如 "app-1.0"
+	BasePath string
+	// 跳过匹配的文件或目录（glob）
+	Exclude []string
+	// 非空时使用 WinZip 兼容的 AES-256 加密文件内容
+	Password string
+}
+
+// ZipFiles 将指定的文件或目录列表打包，目录以其名称作为顶层目录
+func ZipFiles(files []string, dst string, opt ...ZipOptions) error {
+	roots := make([]zipRoot, len(files))
+	for i, f := range files {
+		roots[i] = zipRoot{path: f, name: filepath.Base(filepath.Clean(f))}
+	}
+	return zipRoots(roots, dst, opt...)
+}
+
+type zipRoot struct {
+	path string
+	name string // 空表示目录内容直接位于包根
+}
+
+// zipRoots 打包并在出错时删除不完整的输出文件
+func zipRoots(roots []zipRoot, dst string, opt ...ZipOptions) (err error) {
+	option := ZipOptions{}
+	if len(opt) > 0 {
+		option = opt[0]
+	}
+	b, err := newZipBuilder(option, dst)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			_ = os.Remove(dst)
+		}
+	}()
+	b.w = zip.NewWriter(f)
+	if option.Level != 0 {
+		b.w.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(out, option.Level)
+		})
+	}
+	for _, r := range roots {
+		if err := b.addRoot(r); err != nil {
+			return err
+		}
+	}
+	if option.Comment != "" {
+		if err := b.w.SetComment(option.Comment); err != nil {
+			return err
+		}
+	}
+	return b.w.Close()
+}
+
+type zipBuilder struct {
+	w      *zip.Writer
+	opt    ZipOptions
+	filter *filter
+	store  map[string]bool
+	dst    string // 输出文件绝对路径，遍历时跳过自身
+}
+
+func newZipBuilder(opt ZipOptions, dst string) (*zipBuilder, error) {
+	flt, err := newFilter(nil, opt.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	abs, err := filepath.Abs(dst)
+	if err != nil {
+		return nil, err
+	}
+	exts := opt.StoreExts
+	if exts == nil {
+		exts = DefaultStoreExts
+	}
+	store := make(map[string]bool, len(exts))
+	for _, e := range exts {
+		store[strings.ToLower(e)] = true
+	}
+	return &zipBuilder{opt: opt, filter: flt, store: store, dst: abs}, nil
+}
+
+func (b *zipBuilder) addRoot(r zipRoot) error {
+	info, err := os.Lstat(r.path)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		if b.filter.excluded(r.name) {
+			return nil
+		}
+		return b.addEntry(r.path, r.name, info)
+	}
+	return filepath.Walk(r.path, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(r.path, p)
+		if err != nil {
+			return err
+		}
+		name := path.Join(r.name, filepath.ToSlash(rel))
+		if name == "." {
+			return nil
+		}
+		if b.filter.excluded(name) {
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if abs, _ := filepath.Abs(p); abs == b.dst {
+			return nil
+		}
+		return b.addEntry(p, name, fi)
+	})
+}
+
+// addEntry 写入单个条目，每个文件在返回前关闭
+func (b *zipBuilder) addEntry(p, name string, fi os.FileInfo) error {
+	link := ""
+	if fi.Mode()&os.ModeSymlink != 0 {
+		if b.opt.Symlinks {
+			l, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			link = l
+		} else {
+			st, err := os.Stat(p)
+			if err != nil {
+				return err
+			}
+			if st.IsDir() {
+				return nil
+			}
+			fi = st
+		}
+	}
+	if !fi.IsDir() && !fi.Mode().IsRegular() && link == "" {
+		// 设备、管道等特殊文件跳过
+		return nil
+	}
+	hdr, err := zip.FileInfoHeader(fi)
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	if b.opt.BasePath != "" {
+		hdr.Name = path.Join(strings.Trim(filepath.ToSlash(b.opt.BasePath), "/"), name)
+	}
+	if fi.IsDir() {
+		hdr.Name += "/"
+		hdr.Method = zip.Store
+		hdr.UncompressedSize64 = 0
+		_, err := b.w.CreateHeader(hdr)
+		return err
+	}
+	var src io.Reader
+	if link != "" {
+		hdr.Method = zip.Store
+		hdr.UncompressedSize64 = uint64(len(link))
+		src = strings.NewReader(link)
+	} else {
+		hdr.Method = b.method(name)
+		file, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		src = file
+	}
+	if b.opt.Password != "" {
+		return b.writeEncrypted(hdr, src)
+	}
+	fw, err := b.w.CreateHeader(hdr)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(fw, src)
+	return err
+}
+
+func (b *zipBuilder) method(name string) uint16 {
+	if b.opt.Store || b.store[strings.ToLower(path.Ext(name))] {
+		return zip.Store
+	}
+	return zip.Deflate
+}
+
+// writeEncrypted 先压缩到临时缓冲得到大小，再以 AES 加密写入原始条目
+func (b *zipBuilder) writeEncrypted(hdr *zip.FileHeader, src io.Reader) error {
+	sp := &spool{}
+	defer sp.Close()
+	var n int64
+	var err error
+	if hdr.Method == zip.Deflate {
+		level := b.opt.Level
+		if level == 0 {
+			level = flate.DefaultCompression
+		}
+		fw, ferr := flate.NewWriter(sp, level)
+		if ferr != nil {
+			return ferr
+		}
+		if n, err = io.Copy(fw, src); err != nil {
+			return err
+		}
+		if err = fw.Close(); err != nil {
+			return err
+		}
+	} else if n, err = io.Copy(sp, src); err != nil {
+		return err
+	}
+	// AE-2 不记录 CRC，完整性由 HMAC 保证
+	hdr.Extra = append(hdr.Extra, aesExtra(hdr.Method)...)
+	hdr.Method = methodAES
+	hdr.Flags |= 0x1
+	hdr.CRC32 = 0
+	hdr.UncompressedSize64 = uint64(n)
+	hdr.CompressedSize64 = uint64(sp.size) + aesOverhead
+	setRawModTime(hdr)
+	fw, err := b.w.CreateRaw(hdr)
+	if err != nil {
+		return err
+	}
+	rd, err := sp.reader()
+	if err != nil {
+		return err
+	}
+	return writeAES(fw, b.opt.Password, rd)
+}
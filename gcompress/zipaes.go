@@ -0,0 +1,102 @@
+package gcompress
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/binary"
+	"io"
+)
+
+// WinZip AES（AE-2）加密：https://www.winzip.com/en/support/aes-encryption/
+const (
+	methodAES      = 99
+	aesExtraID     = 0x9901
+	aesSaltLen     = 16 // AES-256
+	aesKeyLen      = 32
+	aesPwvLen      = 2
+	aesAuthLen     = 10
+	aesIterations  = 1000
+	aesOverhead    = aesSaltLen + aesPwvLen + aesAuthLen
+	aesStrength256 = 3
+)
+
+// aesExtra AES 扩展字段：版本 AE-2、厂商 "AE"、强度、实际压缩方式
+func aesExtra(method uint16) []byte {
+	b := make([]byte, 11)
+	binary.LittleEndian.PutUint16(b[0:], aesExtraID)
+	binary.LittleEndian.PutUint16(b[2:], 7)
+	binary.LittleEndian.PutUint16(b[4:], 2)
+	b[6], b[7] = 'A', 'E'
+	b[8] = aesStrength256
+	binary.LittleEndian.PutUint16(b[9:], method)
+	return b
+}
+
+// writeAES 写出 salt | 口令校验值 | 密文 | 认证码
+func writeAES(w io.Writer, password string, r io.Reader) error {
+	salt := make([]byte, aesSaltLen)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return err
+	}
+	dk, err := pbkdf2.Key(sha1.New, password, salt, aesIterations, 2*aesKeyLen+aesPwvLen)
+	if err != nil {
+		return err
+	}
+	block, err := aes.NewCipher(dk[:aesKeyLen])
+	if err != nil {
+		return err
+	}
+	mac := hmac.New(sha1.New, dk[aesKeyLen:2*aesKeyLen])
+	if _, err := w.Write(salt); err != nil {
+		return err
+	}
+	if _, err := w.Write(dk[2*aesKeyLen:]); err != nil {
+		return err
+	}
+	stream := &winzipCTR{block: block}
+	buf := make([]byte, 32<<10)
+	for {
+		n, rerr := r.Read(buf)
+		if n > 0 {
+			stream.XORKeyStream(buf[:n], buf[:n])
+			mac.Write(buf[:n])
+			if _, err := w.Write(buf[:n]); err != nil {
+				return err
+			}
+		}
+		if rerr == io.EOF {
+			break
+		}
+		if rerr != nil {
+			return rerr
+		}
+	}
+	_, err = w.Write(mac.Sum(nil)[:aesAuthLen])
+	return err
+}
+
+// winzipCTR WinZip 使用的 CTR 模式：计数器小端序、从 1 开始，与 cipher.NewCTR 不兼容
+type winzipCTR struct {
+	block   cipher.Block
+	counter uint64
+	ks      [aes.BlockSize]byte
+	pos     int
+}
+
+func (s *winzipCTR) XORKeyStream(dst, src []byte) {
+	for i := range src {
+		if s.pos == 0 || s.pos == aes.BlockSize {
+			s.counter++
+			var ctr [aes.BlockSize]byte
+			binary.LittleEndian.PutUint64(ctr[:], s.counter)
+			s.block.Encrypt(s.ks[:], ctr[:])
+			s.pos = 0
+		}
+		dst[i] = src[i] ^ s.ks[s.pos]
+		s.pos++
+	}
+}
@@ -0,0 +1,89 @@
+package gcron
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock 时钟，测试时可替换为 FakeClock
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// RealClock 系统时钟（默认）
+var RealClock Clock = realClock{}
+
+// FakeClock 手动推进的假时钟
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// NewFakeClock 创建起始于 now 的假时钟
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (f *FakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *FakeClock) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- f.now
+		return ch
+	}
+	f.waiters = append(f.waiters, fakeWaiter{at: f.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance 时间前进 d，触发到期的等待者
+func (f *FakeClock) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+// Set 将时间设为 t（可向后回拨，模拟系统时钟跳变），触发到期的等待者
+func (f *FakeClock) Set(t time.Time) {
+	f.mu.Lock()
+	f.now = t
+	sort.Slice(f.waiters, func(i, j int) bool { return f.waiters[i].at.Before(f.waiters[j].at) })
+	var due []fakeWaiter
+	rest := f.waiters[:0]
+	for _, w := range f.waiters {
+		if !w.at.After(t) {
+			due = append(due, w)
+		} else {
+			rest = append(rest, w)
+		}
+	}
+	f.waiters = rest
+	f.mu.Unlock()
+	for _, w := range due {
+		w.ch <- t
+	}
+}
+
+// Waiters 当前等待中的数量，测试中可用于等待调度协程进入休眠
+func (f *FakeClock) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
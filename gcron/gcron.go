@@ -1,36 +1,240 @@
 package gcron
 
 import (
+	"context"
+	"fmt"
+	"math/rand"
+	"runtime/debug"
 	"sync"
 	"time"
 )
 
+// Overlap 上一次执行未结束时新触发的处理策略
+type Overlap int
+
+const (
+	// OverlapSkip 跳过本次触发（默认）
+	OverlapSkip Overlap = iota
+	// OverlapQueue 排队，上一次结束后依次补跑
+	OverlapQueue
+	// OverlapAllow 允许并发执行
+	OverlapAllow
+)
+
+// Options 定时任务选项
+type Options struct {
+	// 启动后立即执行一次
+	Immediate bool
+	// 每次触发前随机延迟 [0, Jitter)，用于错峰
+	Jitter time.Duration
+	// 重叠策略
+	Overlap Overlap
+	// 任务返回错误或 panic 时回调
+	OnError func(err error)
+	// 时钟，nil 表示系统时钟；测试时可传入 FakeClock
+	Clock Clock
+}
+
+// PanicError 任务 panic 时转换得到的错误
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("gcron: job panic: %v", e.Value)
+}
+
+// Stats 运行统计
+type Stats struct {
+	Runs         int64         // 已完成的执行次数
+	Errors       int64         // 出错（含 panic）次数
+	Skipped      int64         // 因重叠被跳过的触发次数
+	Running      int           // 正在执行的数量
+	LastStart    time.Time     // 最近一次开始时间
+	LastDuration time.Duration // 最近一次耗时
+	LastErr      error         // 最近一次执行的错误
+}
+
+// maxSleep 单次休眠上限，醒来后按墙上时间重新计算，以应对系统时钟跳变
+const maxSleep = time.Minute
+
 type Cron struct {
-	ticker *time.Ticker
-	stop   chan struct{}
-	wg     sync.WaitGroup
+	sched   Schedule
+	clock   Clock
+	fn      func(ctx context.Context) error
+	opt     Options
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	once    sync.Once
+	mu      sync.Mutex
+	stats   Stats
+	pending int
 }
 
-func Every(interval time.Duration, fn func()) *Cron {
-	c := &Cron{stop: make(chan struct{})}
-	c.ticker = time.NewTicker(interval)
+// Every 每隔 interval 执行一次 fn，interval 不为正时 panic
+func Every(interval time.Duration, fn func(), opt ...Options) *Cron {
+	return Run(mustInterval(interval), fn, opt...)
+}
+
+// EveryContext 每隔 interval 执行一次 fn，ctx 取消或 Stop 时传入 fn 的 ctx 随之取消
+// interval 不为正时 panic
+func EveryContext(ctx context.Context, interval time.Duration, fn func(ctx context.Context) error, opt ...Options) *Cron {
+	return RunContext(ctx, mustInterval(interval), fn, opt...)
+}
+
+func mustInterval(d time.Duration) Interval {
+	if d <= 0 {
+		panic("gcron: non-positive interval")
+	}
+	return Interval(d)
+}
+
+// Run 按 schedule 执行 fn，如 Run(DailyAt(2, 30, loc), fn)
+func Run(s Schedule, fn func(), opt ...Options) *Cron {
+	return RunContext(context.Background(), s, func(context.Context) error {
+		fn()
+		return nil
+	}, opt...)
+}
+
+// RunContext 按 schedule 执行 fn，ctx 取消或 Stop 时传入 fn 的 ctx 随之取消
+func RunContext(ctx context.Context, s Schedule, fn func(ctx context.Context) error, opt ...Options) *Cron {
+	c := &Cron{sched: s, fn: fn, clock: RealClock}
+	if len(opt) > 0 {
+		c.opt = opt[0]
+	}
+	if c.opt.Clock != nil {
+		c.clock = c.opt.Clock
+	}
+	c.ctx, c.cancel = context.WithCancel(ctx)
 	c.wg.Add(1)
-	go func() {
-		defer c.wg.Done()
-		for {
+	go c.loop()
+	return c
+}
+
+// loop 计算下一次触发时间并等待；错过的多次触发（进程阻塞、时钟前跳）只补一次
+func (c *Cron) loop() {
+	defer c.wg.Done()
+	if c.opt.Immediate {
+		c.fire()
+	}
+	next := c.sched.Next(c.clock.Now())
+	for !next.IsZero() {
+		if !c.wait(next) {
+			return
+		}
+		c.fire()
+		now := c.clock.Now()
+		n := c.sched.Next(next)
+		if !n.IsZero() && !n.After(now) {
+			n = c.sched.Next(now)
+		}
+		next = n
+	}
+}
+
+// wait 等待到 next，返回 false 表示已停止
+func (c *Cron) wait(next time.Time) bool {
+	for {
+		d := next.Sub(c.clock.Now())
+		if d <= 0 {
+			return true
+		}
+		select {
+		case <-c.clock.After(min(d, maxSleep)):
+		case <-c.ctx.Done():
+			return false
+		}
+	}
+}
+
+// fire 按重叠策略处理一次触发
+func (c *Cron) fire() {
+	c.mu.Lock()
+	if c.stats.Running > 0 {
+		switch c.opt.Overlap {
+		case OverlapSkip:
+			c.stats.Skipped++
+			c.mu.Unlock()
+			return
+		case OverlapQueue:
+			c.pending++
+			c.mu.Unlock()
+			return
+		}
+	}
+	c.stats.Running++
+	c.mu.Unlock()
+	c.wg.Add(1)
+	go c.run()
+}
+
+// run 执行任务，OverlapQueue 下依次补跑排队的触发
+func (c *Cron) run() {
+	defer c.wg.Done()
+	for {
+		if c.opt.Jitter > 0 {
 			select {
-			case <-c.ticker.C:
-				fn()
-			case <-c.stop:
-				return
+			case <-c.clock.After(time.Duration(rand.Int63n(int64(c.opt.Jitter)))):
+			case <-c.ctx.Done():
 			}
 		}
+		if c.ctx.Err() == nil {
+			c.exec()
+		}
+		c.mu.Lock()
+		if c.pending > 0 && c.ctx.Err() == nil {
+			c.pending--
+			c.mu.Unlock()
+			continue
+		}
+		c.pending = 0
+		c.stats.Running--
+		c.mu.Unlock()
+		return
+	}
+}
+
+// exec 执行一次并记录统计，panic 转为 *PanicError
+func (c *Cron) exec() {
+	start := c.clock.Now()
+	c.mu.Lock()
+	c.stats.LastStart = start
+	c.mu.Unlock()
+	err := c.call()
+	c.mu.Lock()
+	c.stats.Runs++
+	c.stats.LastDuration = c.clock.Now().Sub(start)
+	c.stats.LastErr = err
+	if err != nil {
+		c.stats.Errors++
+	}
+	c.mu.Unlock()
+	if err != nil && c.opt.OnError != nil {
+		c.opt.OnError(err)
+	}
+}
+
+func (c *Cron) call() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
 	}()
-	return c
+	return c.fn(c.ctx)
+}
+
+// Stats 返回运行统计快照
+func (c *Cron) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
 }
 
+// Stop 停止触发，取消任务 ctx 并等待正在执行的任务结束，可重复调用
 func (c *Cron) Stop() {
-	c.ticker.Stop()
-	close(c.stop)
+	c.once.Do(c.cancel)
 	c.wg.Wait()
 }
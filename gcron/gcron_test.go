@@ -0,0 +1,48 @@
+package gcron
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEveryRejectsNonPositiveInterval(t *testing.T) {
+	for _, d := range []time.Duration{0, -time.Second} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Every(%v) did not panic", d)
+				}
+			}()
+			Every(d, func() {})
+		}()
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("EveryContext(%v) did not panic", d)
+				}
+			}()
+			EveryContext(context.Background(), d, func(context.Context) error { return nil })
+		}()
+	}
+}
+
+func TestNonPositiveIntervalNeverFires(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	var runs int32
+	c := Run(Interval(0), func() { atomic.AddInt32(&runs, 1) }, Options{Clock: clock})
+	done := make(chan struct{})
+	go func() {
+		c.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Stop blocked: loop is spinning")
+	}
+	if runs := atomic.LoadInt32(&runs); runs != 0 {
+		t.Fatalf("runs = %d, want 0", runs)
+	}
+}
@@ -0,0 +1,152 @@
+package gcron
+
+import (
+	"time"
+)
+
+// Schedule 返回 t 之后的下一次触发时间，零值表示不再触发
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+// Interval 固定间隔，按上一次计划时间累加，不随执行耗时漂移；不为正时不再触发
+type Interval time.Duration
+
+func (i Interval) Next(t time.Time) time.Time {
+	if i <= 0 {
+		return time.Time{}
+	}
+	return t.Add(time.Duration(i))
+}
+
+// Aligned 在整点边界触发，如 Aligned(15*time.Minute, loc) 在每小时 :00 :15 :30 :45 触发
+// interval 不超过 24h，loc 为 nil 时使用 time.Local
+//
+// 能整除 1 小时的间隔按真实时间等距触发，夏令时切换当天也不会漏跑或重复；
+// 其他间隔从当地零点起按墙上时间对齐（如 6h 在 00:00 06:00 12:00 18:00），
+// 落在夏令时跳过区间内的时刻顺延到跳变之后
+func Aligned(interval time.Duration, loc *time.Location) Schedule {
+	if loc == nil {
+		loc = time.Local
+	}
+	return aligned{interval: interval, loc: loc}
+}
+
+type aligned struct {
+	interval time.Duration
+	loc      *time.Location
+}
+
+func (a aligned) Next(t time.Time) time.Time {
+	if a.interval <= 0 {
+		return time.Time{}
+	}
+	t = t.Round(0)
+	if time.Hour%a.interval == 0 {
+		// 以当地偏移后的 Unix 时间对齐
+		_, off := t.In(a.loc).Zone()
+		shift := time.Duration(off) * time.Second
+		w := time.Duration(t.UnixNano()) + shift
+		next := time.Unix(0, int64((w/a.interval+1)*a.interval-shift)).In(a.loc)
+		// 偏移在 t 与 next 之间发生变化时，以新偏移重新对齐
+		if _, off2 := next.Zone(); off2 != off {
+			shift2 := time.Duration(off2) * time.Second
+			w2 := time.Duration(next.UnixNano()) + shift2
+			if n2 := time.Unix(0, int64(w2/a.interval*a.interval-shift2)).In(a.loc); n2.After(t) {
+				next = n2
+			}
+		}
+		return next
+	}
+	lt := t.In(a.loc)
+	for day := 0; day < 3; day++ {
+		for k := time.Duration(0); k < 24*time.Hour; k += a.interval {
+			if c, ok := wallNext(lt.Year(), lt.Month(), lt.Day()+day, k, a.loc, t); ok {
+				return c
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// wallNext 返回 y-m-d 零点后墙上时间 k 处在 loc 中晚于 t 的时刻
+// 落在夏令时跳过区间内的时刻顺延到跳变之后；重复出现的时刻只取较早的一次，较早的一次不晚于 t 时返回 false
+func wallNext(y int, mo time.Month, d int, k time.Duration, loc *time.Location, t time.Time) (time.Time, bool) {
+	y, mo, d = time.Date(y, mo, d, 12, 0, 0, 0, loc).Date()
+	wall := time.Date(y, mo, d, 0, 0, 0, int(k), time.UTC)
+	c := time.Date(y, mo, d, 0, 0, 0, int(k), loc)
+	// 夏令时切换相隔数月，前后 6 小时内至多一次
+	_, before := c.Add(-6 * time.Hour).Zone()
+	_, after := c.Add(6 * time.Hour).Zone()
+	if before == after {
+		return c, c.After(t)
+	}
+	u1 := wall.Add(-time.Duration(before) * time.Second).In(loc)
+	u2 := wall.Add(-time.Duration(after) * time.Second).In(loc)
+	ok1, ok2 := sameWall(u1, wall), sameWall(u2, wall)
+	switch {
+	case ok1 && ok2:
+		early := u1
+		if u2.Before(u1) {
+			early = u2
+		}
+		return early, early.After(t)
+	case ok1:
+		return u1, u1.After(t)
+	case ok2:
+		return u2, u2.After(t)
+	}
+	// 跳过区间：按切换前的偏移解释，即顺延跳变的时长
+	late := u1
+	if u2.After(u1) {
+		late = u2
+	}
+	return late, late.After(t)
+}
+
+func sameWall(u, wall time.Time) bool {
+	y1, m1, d1 := u.Date()
+	y2, m2, d2 := wall.Date()
+	return y1 == y2 && m1 == m2 && d1 == d2 && u.Hour() == wall.Hour() && u.Minute() == wall.Minute() &&
+		u.Second() == wall.Second() && u.Nanosecond() == wall.Nanosecond()
+}
+
+// DailyAt 每天在 loc 时区的 hour:minute 触发，loc 为 nil 时使用 time.Local
+// 夏令时跳过的时刻顺延到跳变之后；重复的时刻只触发一次
+func DailyAt(hour, minute int, loc *time.Location) Schedule {
+	if loc == nil {
+		loc = time.Local
+	}
+	return weekly{days: 0x7f, hour: hour, minute: minute, loc: loc}
+}
+
+// WeeklyAt 每周 day 在 loc 时区的 hour:minute 触发，loc 为 nil 时使用 time.Local
+func WeeklyAt(day time.Weekday, hour, minute int, loc *time.Location) Schedule {
+	if loc == nil {
+		loc = time.Local
+	}
+	return weekly{days: 1 << day, hour: hour, minute: minute, loc: loc}
+}
+
+// weekly days 为星期位图，bit0 表示周日
+type weekly struct {
+	days         uint8
+	hour, minute int
+	loc          *time.Location
+}
+
+func (w weekly) Next(t time.Time) time.Time {
+	t = t.Round(0)
+	lt := t.In(w.loc)
+	k := time.Duration(w.hour)*time.Hour + time.Duration(w.minute)*time.Minute
+	for i := 0; i <= 7; i++ {
+		day := time.Date(lt.Year(), lt.Month(), lt.Day()+i, 12, 0, 0, 0, w.loc)
+		if w.days&(1<<day.Weekday()) == 0 {
+			continue
+		}
+		if c, ok := wallNext(day.Year(), day.Month(), day.Day(), k, w.loc, t); ok {
+			return c
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,200 @@
+package gcron
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+// harness 用 FakeClock 驱动 Run，每次调整时钟后等待调度协程重新休眠
+type harness struct {
+	t     *testing.T
+	clock *FakeClock
+	c     *Cron
+	runs  int64
+}
+
+func start(t *testing.T, s Schedule, now time.Time) *harness {
+	t.Helper()
+	h := &harness{t: t, clock: NewFakeClock(now)}
+	h.c = Run(s, func() {}, Options{Clock: h.clock})
+	t.Cleanup(h.c.Stop)
+	h.idle()
+	return h
+}
+
+// idle 等待调度协程进入休眠且没有正在执行的任务
+func (h *harness) idle() {
+	h.t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for h.clock.Waiters() == 0 || h.c.Stats().Running > 0 {
+		if time.Now().After(deadline) {
+			h.t.Fatal("scheduler did not go back to sleep")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// set 将时钟调到 at，返回期间新增的执行次数
+func (h *harness) set(at time.Time) int64 {
+	h.t.Helper()
+	h.clock.Set(at)
+	h.idle()
+	runs := h.c.Stats().Runs
+	n := runs - h.runs
+	h.runs = runs
+	return n
+}
+
+// expect 确认恰好在 times 的各个时刻各执行一次，之前一秒不执行
+func (h *harness) expect(times ...time.Time) {
+	h.t.Helper()
+	for _, at := range times {
+		if n := h.set(at.Add(-time.Second)); n != 0 {
+			h.t.Fatalf("%d runs before %s", n, at)
+		}
+		if n := h.set(at); n != 1 {
+			h.t.Fatalf("%d runs at %s, want 1", n, at)
+		}
+		if got := h.c.Stats().LastStart; !got.Equal(at) {
+			h.t.Fatalf("run started at %s, want %s", got, at)
+		}
+	}
+}
+
+// expectNone 确认调到 at 时不执行
+func (h *harness) expectNone(at time.Time) {
+	h.t.Helper()
+	if n := h.set(at); n != 0 {
+		h.t.Fatalf("%d unexpected runs at %s", n, at)
+	}
+}
+
+func newYork(t *testing.T) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func utc(mo time.Month, d, h, m int) time.Time {
+	return time.Date(2024, mo, d, h, m, 0, 0, time.UTC)
+}
+
+// 2024 年纽约夏令时：3 月 10 日 02:00 EST 跳到 03:00 EDT，11 月 3 日 02:00 EDT 回拨到 01:00 EST
+
+func TestDailyAtSpringForward(t *testing.T) {
+	h := start(t, DailyAt(2, 30, newYork(t)), utc(time.March, 8, 17, 0))
+	h.expect(
+		utc(time.March, 9, 7, 30),  // 02:30 EST
+		utc(time.March, 10, 7, 30), // 02:30 不存在，顺延到 03:30 EDT
+		utc(time.March, 11, 6, 30), // 02:30 EDT
+	)
+}
+
+func TestDailyAtFallBack(t *testing.T) {
+	h := start(t, DailyAt(1, 30, newYork(t)), utc(time.November, 1, 17, 0))
+	h.expect(
+		utc(time.November, 2, 5, 30), // 01:30 EDT
+		utc(time.November, 3, 5, 30), // 第一次 01:30 EDT
+	)
+	h.expectNone(utc(time.November, 3, 6, 30)) // 第二次 01:30 EST 不重复
+	h.expect(utc(time.November, 4, 6, 30))     // 01:30 EST
+}
+
+func TestWeeklyAtAcrossDST(t *testing.T) {
+	loc := newYork(t)
+	h := start(t, WeeklyAt(time.Sunday, 2, 30, loc), utc(time.March, 1, 12, 0))
+	h.expect(
+		utc(time.March, 3, 7, 30),  // 02:30 EST
+		utc(time.March, 10, 7, 30), // 03:30 EDT
+		utc(time.March, 17, 6, 30), // 02:30 EDT
+	)
+
+	h = start(t, WeeklyAt(time.Sunday, 1, 30, loc), utc(time.October, 25, 12, 0))
+	h.expect(
+		utc(time.October, 27, 5, 30), // 01:30 EDT
+		utc(time.November, 3, 5, 30), // 第一次 01:30 EDT
+	)
+	h.expectNone(utc(time.November, 3, 6, 30))
+	h.expect(utc(time.November, 10, 6, 30)) // 01:30 EST
+}
+
+func TestAlignedSubHourAcrossDST(t *testing.T) {
+	loc := newYork(t)
+	// 能整除 1 小时的间隔按真实时间等距触发
+	h := start(t, Aligned(30*time.Minute, loc), utc(time.March, 10, 6, 10))
+	h.expect(
+		utc(time.March, 10, 6, 30), // 01:30 EST
+		utc(time.March, 10, 7, 0),  // 03:00 EDT
+		utc(time.March, 10, 7, 30), // 03:30 EDT
+	)
+
+	h = start(t, Aligned(30*time.Minute, loc), utc(time.November, 3, 4, 40))
+	h.expect(
+		utc(time.November, 3, 5, 0),  // 01:00 EDT
+		utc(time.November, 3, 5, 30), // 01:30 EDT
+		utc(time.November, 3, 6, 0),  // 01:00 EST
+		utc(time.November, 3, 6, 30), // 01:30 EST
+		utc(time.November, 3, 7, 0),  // 02:00 EST
+	)
+}
+
+func TestAlignedHoursAcrossDST(t *testing.T) {
+	loc := newYork(t)
+	// 其他间隔按墙上时间对齐
+	h := start(t, Aligned(6*time.Hour, loc), utc(time.March, 9, 23, 0))
+	h.expect(
+		utc(time.March, 10, 5, 0),  // 00:00 EST
+		utc(time.March, 10, 10, 0), // 06:00 EDT
+		utc(time.March, 10, 16, 0), // 12:00 EDT
+	)
+
+	h = start(t, Aligned(6*time.Hour, loc), utc(time.November, 2, 23, 0))
+	h.expect(
+		utc(time.November, 3, 4, 0),  // 00:00 EDT
+		utc(time.November, 3, 11, 0), // 06:00 EST
+		utc(time.November, 3, 17, 0), // 12:00 EST
+	)
+}
+
+func TestForwardJumpCatchesUpOnce(t *testing.T) {
+	h := start(t, DailyAt(9, 0, time.UTC), utc(time.January, 1, 8, 0))
+	h.expect(utc(time.January, 1, 9, 0))
+	// 跳过 4 次触发，只补跑一次
+	if n := h.set(utc(time.January, 6, 15, 0)); n != 1 {
+		t.Fatalf("%d catch-up runs, want 1", n)
+	}
+	h.expectNone(utc(time.January, 6, 23, 0))
+	h.expect(utc(time.January, 7, 9, 0))
+}
+
+func TestForwardJumpAcrossDSTCatchesUpOnce(t *testing.T) {
+	h := start(t, DailyAt(2, 30, newYork(t)), utc(time.March, 8, 17, 0))
+	if n := h.set(utc(time.March, 12, 17, 0)); n != 1 {
+		t.Fatalf("%d catch-up runs, want 1", n)
+	}
+	h.expect(utc(time.March, 13, 6, 30)) // 02:30 EDT
+}
+
+func TestIntervalForwardJumpCatchesUpOnce(t *testing.T) {
+	h := start(t, Interval(time.Hour), utc(time.January, 1, 0, 0))
+	h.expect(utc(time.January, 1, 1, 0), utc(time.January, 1, 2, 0))
+	if n := h.set(utc(time.January, 1, 12, 30)); n != 1 {
+		t.Fatalf("%d catch-up runs, want 1", n)
+	}
+	// 补跑后从当前时间重新计时
+	h.expect(utc(time.January, 1, 13, 30))
+}
+
+func TestBackwardJumpDoesNotRepeat(t *testing.T) {
+	h := start(t, DailyAt(9, 0, time.UTC), utc(time.January, 1, 8, 0))
+	h.expect(utc(time.January, 1, 9, 0))
+	// 回拨到已执行过的时刻之前，不重复执行
+	h.expectNone(time.Date(2023, time.December, 31, 12, 0, 0, 0, time.UTC))
+	h.expectNone(utc(time.January, 1, 9, 0))
+	h.expectNone(utc(time.January, 1, 9, 30))
+	h.expect(utc(time.January, 2, 9, 0))
+}
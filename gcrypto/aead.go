@@ -0,0 +1,239 @@
+package gcrypto
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/tjfoc/gmsm/sm4"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// AEADAlg 认证加密算法
+type AEADAlg string
+
+const (
+	AlgAESGCM           AEADAlg = "AES-GCM"           // 密钥 16/24/32 字节
+	AlgChaCha20Poly1305 AEADAlg = "ChaCha20-Poly1305" // 密钥 32 字节
+	AlgSM4GCM           AEADAlg = "SM4-GCM"           // 密钥 16 字节
+	AlgSM4CCM           AEADAlg = "SM4-CCM"           // 密钥 16 字节
+)
+
+var (
+	ErrUnsupportedAEAD = errors.New("gcrypto: unsupported aead algorithm")
+	ErrCiphertextShort = errors.New("gcrypto: ciphertext too short")
+)
+
+// NewAEAD 创建 alg 的 cipher.AEAD，nonce 12 字节、tag 16 字节
+func NewAEAD(alg AEADAlg, key []byte) (cipher.AEAD, error) {
+	switch alg {
+	case AlgAESGCM:
+		return newAESGCM(key)
+	case AlgChaCha20Poly1305:
+		return chacha20poly1305.New(key)
+	case AlgSM4GCM, AlgSM4CCM:
+		block, err := sm4.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		if alg == AlgSM4GCM {
+			return cipher.NewGCM(block)
+		}
+		return NewCCM(block, 12, 16)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedAEAD, alg)
+}
+
+// AEADEncrypt 用随机 nonce 加密，输出 nonce||ciphertext||tag
+func AEADEncrypt(alg AEADAlg, key, plain, aad []byte) ([]byte, error) {
+	a, err := NewAEAD(alg, key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, a.NonceSize(), a.NonceSize()+len(plain)+a.Overhead())
+	if _, err := io.ReadFull(rand.Reader, out); err != nil {
+		return nil, err
+	}
+	return a.Seal(out, out[:a.NonceSize()], plain, aad), nil
+}
+
+// AEADDecrypt 解密 AEADEncrypt 的输出，密文或 aad 被篡改时返回 ErrDecrypt
+func AEADDecrypt(alg AEADAlg, key, data, aad []byte) ([]byte, error) {
+	a, err := NewAEAD(alg, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < a.NonceSize()+a.Overhead() {
+		return nil, ErrCiphertextShort
+	}
+	plain, err := a.Open(nil, data[:a.NonceSize()], data[a.NonceSize():], aad)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plain, nil
+}
+
+func AESGCMEncrypt(key, plain, aad []byte) ([]byte, error) {
+	return AEADEncrypt(AlgAESGCM, key, plain, aad)
+}
+
+func AESGCMDecrypt(key, data, aad []byte) ([]byte, error) {
+	return AEADDecrypt(AlgAESGCM, key, data, aad)
+}
+
+func ChaCha20Poly1305Encrypt(key, plain, aad []byte) ([]byte, error) {
+	return AEADEncrypt(AlgChaCha20Poly1305, key, plain, aad)
+}
+
+func ChaCha20Poly1305Decrypt(key, data, aad []byte) ([]byte, error) {
+	return AEADDecrypt(AlgChaCha20Poly1305, key, data, aad)
+}
+
+func SM4GCMEncrypt(key, plain, aad []byte) ([]byte, error) {
+	return AEADEncrypt(AlgSM4GCM, key, plain, aad)
+}
+
+func SM4GCMDecrypt(key, data, aad []byte) ([]byte, error) {
+	return AEADDecrypt(AlgSM4GCM, key, data, aad)
+}
+
+func SM4CCMEncrypt(key, plain, aad []byte) ([]byte, error) {
+	return AEADEncrypt(AlgSM4CCM, key, plain, aad)
+}
+
+func SM4CCMDecrypt(key, data, aad []byte) ([]byte, error) {
+	return AEADDecrypt(AlgSM4CCM, key, data, aad)
+}
+
+// ---------------- CCM (NIST SP 800-38C) ----------------
+
+type ccm struct {
+	b         cipher.Block
+	nonceSize int
+	tagSize   int
+	maxLen    uint64
+}
+
+// NewCCM 基于 16 字节分组密码创建 CCM 模式，nonceSize 取 7~13，tagSize 取 4~16 的偶数
+func NewCCM(b cipher.Block, nonceSize, tagSize int) (cipher.AEAD, error) {
+	if b.BlockSize() != 16 {
+		return nil, errors.New("gcrypto: ccm requires a 16-byte block cipher")
+	}
+	if nonceSize < 7 || nonceSize > 13 {
+		return nil, errors.New("gcrypto: invalid ccm nonce size")
+	}
+	if tagSize < 4 || tagSize > 16 || tagSize%2 != 0 {
+		return nil, errors.New("gcrypto: invalid ccm tag size")
+	}
+	l := 15 - nonceSize
+	maxLen := uint64(1)<<(8*l) - 1
+	if l >= 8 {
+		maxLen = 1<<63 - 1
+	}
+	return &ccm{b: b, nonceSize: nonceSize, tagSize: tagSize, maxLen: maxLen}, nil
+}
+
+func (c *ccm) NonceSize() int { return c.nonceSize }
+func (c *ccm) Overhead() int  { return c.tagSize }
+
+func (c *ccm) Seal(dst, nonce, plain, aad []byte) []byte {
+	if len(nonce) != c.nonceSize {
+		panic("gcrypto: incorrect nonce length given to CCM")
+	}
+	if uint64(len(plain)) > c.maxLen {
+		panic("gcrypto: message too large for CCM")
+	}
+	tag := c.mac(nonce, plain, aad)
+	ret, out := sliceForAppend(dst, len(plain)+c.tagSize)
+	s0 := c.ctr(nonce, out[:len(plain)], plain)
+	subtle.XORBytes(out[len(plain):], tag[:c.tagSize], s0[:c.tagSize])
+	return ret
+}
+
+func (c *ccm) Open(dst, nonce, data, aad []byte) ([]byte, error) {
+	if len(nonce) != c.nonceSize {
+		return nil, errors.New("gcrypto: incorrect nonce length given to CCM")
+	}
+	if len(data) < c.tagSize || uint64(len(data)-c.tagSize) > c.maxLen {
+		return nil, ErrDecrypt
+	}
+	ct, tag := data[:len(data)-c.tagSize], data[len(data)-c.tagSize:]
+	ret, out := sliceForAppend(dst, len(ct))
+	s0 := c.ctr(nonce, out, ct)
+	expected := c.mac(nonce, out, aad)
+	subtle.XORBytes(expected[:c.tagSize], expected[:c.tagSize], s0[:c.tagSize])
+	if subtle.ConstantTimeCompare(expected[:c.tagSize], tag) != 1 {
+		clear(out)
+		return nil, ErrDecrypt
+	}
+	return ret, nil
+}
+
+// ctr 以计数器 1 起加解密 src 到 dst，返回计数器 0 的密钥流 S0
+func (c *ccm) ctr(nonce, dst, src []byte) []byte {
+	var a [16]byte
+	a[0] = byte(14 - c.nonceSize)
+	copy(a[1:], nonce)
+	s0 := make([]byte, 16)
+	c.b.Encrypt(s0, a[:])
+	a[15] = 1
+	cipher.NewCTR(c.b, a[:]).XORKeyStream(dst, src)
+	return s0
+}
+
+// mac CBC-MAC，B0 | 编码后的 aad | 明文，各自补零到分组边界
+func (c *ccm) mac(nonce, plain, aad []byte) []byte {
+	l := 15 - c.nonceSize
+	var b0 [16]byte
+	b0[0] = byte((c.tagSize-2)/2<<3 | (l - 1))
+	if len(aad) > 0 {
+		b0[0] |= 0x40
+	}
+	copy(b0[1:], nonce)
+	n := uint64(len(plain))
+	for i := 15; i > c.nonceSize; i-- {
+		b0[i] = byte(n)
+		n >>= 8
+	}
+	y := make([]byte, 16)
+	c.b.Encrypt(y, b0[:])
+	if len(aad) > 0 {
+		var hdr []byte
+		switch n := uint64(len(aad)); {
+		case n < 0xff00:
+			hdr = binary.BigEndian.AppendUint16(nil, uint16(n))
+		case n <= 0xffffffff:
+			hdr = binary.BigEndian.AppendUint32([]byte{0xff, 0xfe}, uint32(n))
+		default:
+			hdr = binary.BigEndian.AppendUint64([]byte{0xff, 0xff}, n)
+		}
+		c.cbc(y, append(hdr, aad...))
+	}
+	c.cbc(y, plain)
+	return y
+}
+
+// cbc 对补零到分组边界的 data 继续 CBC-MAC
+func (c *ccm) cbc(y, data []byte) {
+	for len(data) > 0 {
+		n := min(len(data), 16)
+		subtle.XORBytes(y[:n], y[:n], data[:n])
+		c.b.Encrypt(y, y)
+		data = data[n:]
+	}
+}
+
+// sliceForAppend 扩展 in 以追加 n 字节，返回整体与新增部分
+func sliceForAppend(in []byte, n int) (head, tail []byte) {
+	if total := len(in) + n; cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+	return head, head[len(in):]
+}
@@ -0,0 +1,199 @@
+package gcrypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"errors"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm4"
+)
+
+func TestCCMVectors(t *testing.T) {
+	for _, c := range []struct {
+		name                  string
+		sm4                   bool
+		key, nonce, aad, text string
+		tagSize               int
+		out                   string
+	}{
+		// RFC 3610 Packet Vector #1
+		{"RFC 3610 #1", false, "c0c1c2c3c4c5c6c7c8c9cacbcccdcecf", "00000003020100a0a1a2a3a4a5", "0001020304050607",
+			"08090a0b0c0d0e0f101112131415161718191a1b1c1d1e", 8,
+			"588c979a61c663d2f066d0c2c0f989806d5f6b61dac38417e8d12cfdf926e0"},
+		// NIST SP 800-38C 附录 C 示例 1~3
+		{"SP 800-38C #1", false, "404142434445464748494a4b4c4d4e4f", "10111213141516", "0001020304050607",
+			"20212223", 4, "7162015b4dac255d"},
+		{"SP 800-38C #2", false, "404142434445464748494a4b4c4d4e4f", "1011121314151617", "000102030405060708090a0b0c0d0e0f",
+			"202122232425262728292a2b2c2d2e2f", 6, "d2a1f0e051ea5f62081a7792073d593d1fc64fbfaccd"},
+		{"SP 800-38C #3", false, "404142434445464748494a4b4c4d4e4f", "101112131415161718191a1b", "000102030405060708090a0b0c0d0e0f10111213",
+			"202122232425262728292a2b2c2d2e2f3031323334353637", 8, "e3b201a9f5b71a7a9b1ceaeccd97e70b6176aad9a4428aa5484392fbc1b09951"},
+		// RFC 8998 附录 A.2 SM4-CCM
+		{"RFC 8998 SM4-CCM", true, "0123456789abcdeffedcba9876543210", "00001234567800000000abcd", "feedfacedeadbeeffeedfacedeadbeefabaddad2",
+			"aaaaaaaaaaaaaaaabbbbbbbbbbbbbbbbccccccccccccccccddddddddddddddddeeeeeeeeeeeeeeeeffffffffffffffffeeeeeeeeeeeeeeeeaaaaaaaaaaaaaaaa", 16,
+			"48af93501fa62adbcd414cce6034d895dda1bf8f132f042098661572e7483094fd12e518ce062c98acee28d95df4416bed31a2f04476c18bb40c84a74b97dc5b" +
+				"16842d4fa186f56ab33256971fa110f4"},
+	} {
+		key := unhex(t, c.key)
+		block, err := aes.NewCipher(key)
+		if c.sm4 {
+			block, err = sm4.NewCipher(key)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		nonce, aad, text, want := unhex(t, c.nonce), unhex(t, c.aad), unhex(t, c.text), unhex(t, c.out)
+		a, err := NewCCM(block, len(nonce), c.tagSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Seal 追加到 dst 之后
+		prefix := []byte("hdr")
+		out := a.Seal(append([]byte(nil), prefix...), nonce, text, aad)
+		if !bytes.Equal(out[:len(prefix)], prefix) || !bytes.Equal(out[len(prefix):], want) {
+			t.Errorf("%s: Seal = %x", c.name, out)
+		}
+		plain, err := a.Open(nil, nonce, want, aad)
+		if err != nil || !bytes.Equal(plain, text) {
+			t.Errorf("%s: Open = %x, %v", c.name, plain, err)
+		}
+		bad := append([]byte(nil), want...)
+		bad[len(bad)-1] ^= 1
+		if _, err := a.Open(nil, nonce, bad, aad); err != ErrDecrypt {
+			t.Errorf("%s: tampered tag: %v", c.name, err)
+		}
+	}
+}
+
+// RFC 8998 附录 A.1 SM4-GCM
+func TestSM4GCMVector(t *testing.T) {
+	a, err := NewAEAD(AlgSM4GCM, unhex(t, "0123456789abcdeffedcba9876543210"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce, aad := unhex(t, "00001234567800000000abcd"), unhex(t, "feedfacedeadbeeffeedfacedeadbeefabaddad2")
+	text := unhex(t, "aaaaaaaaaaaaaaaabbbbbbbbbbbbbbbbccccccccccccccccddddddddddddddddeeeeeeeeeeeeeeeeffffffffffffffffeeeeeeeeeeeeeeeeaaaaaaaaaaaaaaaa")
+	want := unhex(t, "17f399f08c67d5ee19d0dc9969c4bb7d5fd46fd3756489069157b282bb200735d82710ca5c22f0ccfa7cbf93d496ac15a56834cbcf98c397b4024a2691233b8d"+
+		"83de3541e4c2b58177e065a9bf7b62ec")
+	if out := a.Seal(nil, nonce, text, aad); !bytes.Equal(out, want) {
+		t.Errorf("Seal = %x", out)
+	}
+}
+
+func TestNewCCMParameters(t *testing.T) {
+	block, _ := aes.NewCipher(make([]byte, 16))
+	for _, p := range [][2]int{{6, 16}, {14, 16}, {12, 2}, {12, 18}, {12, 7}} {
+		if _, err := NewCCM(block, p[0], p[1]); err == nil {
+			t.Errorf("NewCCM(nonce=%d, tag=%d) accepted", p[0], p[1])
+		}
+	}
+	for _, p := range [][2]int{{7, 4}, {13, 16}, {12, 10}} {
+		if _, err := NewCCM(block, p[0], p[1]); err != nil {
+			t.Errorf("NewCCM(nonce=%d, tag=%d): %v", p[0], p[1], err)
+		}
+	}
+	a, _ := NewCCM(block, 12, 16)
+	if _, err := a.Open(nil, make([]byte, 11), make([]byte, 16), nil); err == nil {
+		t.Error("wrong nonce length accepted")
+	}
+	if _, err := a.Open(nil, make([]byte, 12), make([]byte, 15), nil); err != ErrDecrypt {
+		t.Errorf("short data: %v", err)
+	}
+	// nonce 13 字节时长度字段只有 2 字节，明文不能超过 65535
+	short, _ := NewCCM(block, 13, 16)
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("oversized message did not panic")
+			}
+		}()
+		short.Seal(nil, make([]byte, 13), make([]byte, 1<<16), nil)
+	}()
+}
+
+func TestAEADRoundTrip(t *testing.T) {
+	keys := map[AEADAlg][]int{
+		AlgAESGCM:           {16, 24, 32},
+		AlgChaCha20Poly1305: {32},
+		AlgSM4GCM:           {16},
+		AlgSM4CCM:           {16},
+	}
+	aad := []byte("header")
+	for alg, sizes := range keys {
+		for _, size := range sizes {
+			key := bytes.Repeat([]byte{byte(size)}, size)
+			for _, plain := range [][]byte{nil, []byte("x"), bytes.Repeat([]byte("0123456789abcdef"), 5)} {
+				data, err := AEADEncrypt(alg, key, plain, aad)
+				if err != nil {
+					t.Fatalf("%s/%d: %v", alg, size, err)
+				}
+				if len(data) != 12+len(plain)+16 {
+					t.Errorf("%s/%d: output is %d bytes", alg, size, len(data))
+				}
+				got, err := AEADDecrypt(alg, key, data, aad)
+				if err != nil || !bytes.Equal(got, plain) {
+					t.Errorf("%s/%d: AEADDecrypt = %q, %v", alg, size, got, err)
+				}
+				for i := range data {
+					bad := append([]byte(nil), data...)
+					bad[i] ^= 0x80
+					if _, err := AEADDecrypt(alg, key, bad, aad); err != ErrDecrypt {
+						t.Fatalf("%s/%d: byte %d tampered: %v", alg, size, i, err)
+					}
+				}
+				if _, err := AEADDecrypt(alg, key, data, []byte("headeR")); err != ErrDecrypt {
+					t.Errorf("%s/%d: tampered aad: %v", alg, size, err)
+				}
+				if _, err := AEADDecrypt(alg, key, data, nil); err != ErrDecrypt {
+					t.Errorf("%s/%d: missing aad: %v", alg, size, err)
+				}
+				if _, err := AEADDecrypt(alg, key, data[:len(data)-1], aad); err != ErrDecrypt && err != ErrCiphertextShort {
+					t.Errorf("%s/%d: truncated: %v", alg, size, err)
+				}
+			}
+			for _, n := range []int{0, 12, 27} {
+				if _, err := AEADDecrypt(alg, key, make([]byte, n), aad); err != ErrCiphertextShort {
+					t.Errorf("%s/%d: %d-byte input: %v", alg, size, n, err)
+				}
+			}
+			if _, err := AEADEncrypt(alg, key[:size-1], []byte("x"), nil); err == nil {
+				t.Errorf("%s: %d-byte key accepted", alg, size-1)
+			}
+		}
+	}
+	// 各算法密文互不通用
+	key := make([]byte, 16)
+	data, _ := AEADEncrypt(AlgSM4GCM, key, []byte("x"), nil)
+	if _, err := AEADDecrypt(AlgSM4CCM, key, data, nil); err != ErrDecrypt {
+		t.Errorf("SM4-GCM data opened as SM4-CCM: %v", err)
+	}
+	if _, err := NewAEAD("AES-OCB", key); !errors.Is(err, ErrUnsupportedAEAD) {
+		t.Errorf("unsupported algorithm: %v", err)
+	}
+}
+
+func TestAEADHelpers(t *testing.T) {
+	for _, h := range []struct {
+		alg     AEADAlg
+		keyLen  int
+		encrypt func(key, plain, aad []byte) ([]byte, error)
+		decrypt func(key, data, aad []byte) ([]byte, error)
+	}{
+		{AlgAESGCM, 32, AESGCMEncrypt, AESGCMDecrypt},
+		{AlgChaCha20Poly1305, 32, ChaCha20Poly1305Encrypt, ChaCha20Poly1305Decrypt},
+		{AlgSM4GCM, 16, SM4GCMEncrypt, SM4GCMDecrypt},
+		{AlgSM4CCM, 16, SM4CCMEncrypt, SM4CCMDecrypt},
+	} {
+		key := make([]byte, h.keyLen)
+		data, err := h.encrypt(key, []byte("hello"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := AEADDecrypt(h.alg, key, data, nil); err != nil || string(got) != "hello" {
+			t.Errorf("%s: AEADDecrypt = %q, %v", h.alg, got, err)
+		}
+		if got, err := h.decrypt(key, data, nil); err != nil || string(got) != "hello" {
+			t.Errorf("%s: decrypt = %q, %v", h.alg, got, err)
+		}
+	}
+}
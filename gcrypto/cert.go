@@ -0,0 +1,265 @@
+package gcrypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"time"
+
+	"github.com/tjfoc/gmsm/sm2"
+	"github.com/tjfoc/gmsm/sm3"
+	"github.com/tjfoc/gmsm/x509"
+)
+
+const (
+	pemCertificate        = "CERTIFICATE"
+	pemCertificateRequest = "CERTIFICATE REQUEST"
+
+	// DefaultCertValidity 证书默认有效期
+	DefaultCertValidity = 365 * 24 * time.Hour
+)
+
+var ErrCertificate = errors.New("gcrypto: invalid certificate")
+
+// CertOptions 证书与证书请求选项
+type CertOptions struct {
+	Subject        pkix.Name
+	DNSNames       []string
+	IPAddresses    []net.IP
+	EmailAddresses []string
+
+	SerialNumber *big.Int      // 默认随机 128 位
+	NotBefore    time.Time     // 默认当前时间
+	Validity     time.Duration // 默认 DefaultCertValidity
+	IsCA         bool
+	MaxPathLen   int // 仅 IsCA 时有效，0 表示不限制
+	KeyUsage     x509.KeyUsage
+	ExtKeyUsage  []x509.ExtKeyUsage
+}
+
+// CreateSM2CSR 创建 SM2/SM3 签名的证书请求，返回 PEM
+func CreateSM2CSR(priv *sm2.PrivateKey, opt CertOptions) ([]byte, error) {
+	tmpl := &x509.CertificateRequest{
+		Subject:            opt.Subject,
+		DNSNames:           opt.DNSNames,
+		IPAddresses:        opt.IPAddresses,
+		EmailAddresses:     opt.EmailAddresses,
+		SignatureAlgorithm: x509.SM2WithSM3,
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, tmpl, priv)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: pemCertificateRequest, Bytes: der}), nil
+}
+
+// ParseSM2CSR 解析 PEM 或 DER 格式的证书请求并校验其签名
+func ParseSM2CSR(data []byte) (*x509.CertificateRequest, error) {
+	der, err := pemOrDER(data, pemCertificateRequest)
+	if err != nil {
+		return nil, err
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return nil, err
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, err
+	}
+	return csr, nil
+}
+
+// CreateSM2SelfSignedCert 创建自签名证书（如根 CA），返回 PEM
+func CreateSM2SelfSignedCert(priv *sm2.PrivateKey, opt CertOptions) ([]byte, error) {
+	tmpl, err := certTemplate(opt)
+	if err != nil {
+		return nil, err
+	}
+	return createCert(tmpl, tmpl, &priv.PublicKey, priv)
+}
+
+// IssueSM2Cert 用 CA 证书与私钥为证书请求签发证书，返回 PEM；
+// opt.Subject 为空时使用请求中的主题，opt 未指定的 SAN 取自请求
+func IssueSM2Cert(csr *x509.CertificateRequest, ca *x509.Certificate, caKey *sm2.PrivateKey, opt CertOptions) ([]byte, error) {
+	pub, ok := asSM2PublicKey(csr.PublicKey)
+	if !ok {
+		return nil, ErrUnsupportedKeyType
+	}
+	if len(opt.Subject.ToRDNSequence()) == 0 {
+		opt.Subject = csr.Subject
+	}
+	if opt.DNSNames == nil {
+		opt.DNSNames = csr.DNSNames
+	}
+	if opt.IPAddresses == nil {
+		opt.IPAddresses = csr.IPAddresses
+	}
+	if opt.EmailAddresses == nil {
+		opt.EmailAddresses = csr.EmailAddresses
+	}
+	tmpl, err := certTemplate(opt)
+	if err != nil {
+		return nil, err
+	}
+	return createCert(tmpl, ca, pub, caKey)
+}
+
+// ParseSM2Cert 解析 PEM 或 DER 格式的证书
+func ParseSM2Cert(data []byte) (*x509.Certificate, error) {
+	der, err := pemOrDER(data, pemCertificate)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(der)
+}
+
+// ParseSM2CertsPEM 解析包含多个证书的 PEM（如 CA 证书包），忽略其他类型的块
+func ParseSM2CertsPEM(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != pemCertificate {
+			continue
+		}
+		c, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, c)
+	}
+	if len(certs) == 0 {
+		return nil, ErrCertificate
+	}
+	return certs, nil
+}
+
+// ChainOptions 证书链校验选项
+type ChainOptions struct {
+	Intermediates []*x509.Certificate
+	DNSName       string             // 非空时校验证书是否适用于该域名
+	CurrentTime   time.Time          // 默认当前时间
+	KeyUsages     []x509.ExtKeyUsage // 默认不限制扩展用途
+}
+
+// VerifySM2CertChain 校验 leaf 能否经 Intermediates 链接到 roots 中的某个根证书，返回所有有效的证书链
+func VerifySM2CertChain(leaf *x509.Certificate, roots []*x509.Certificate, opt ...ChainOptions) ([][]*x509.Certificate, error) {
+	option := ChainOptions{}
+	if len(opt) > 0 {
+		option = opt[0]
+	}
+	if len(roots) == 0 {
+		return nil, errors.New("gcrypto: no root certificates")
+	}
+	vo := x509.VerifyOptions{
+		DNSName:       option.DNSName,
+		Roots:         x509.NewCertPool(),
+		Intermediates: x509.NewCertPool(),
+		CurrentTime:   option.CurrentTime,
+		KeyUsages:     option.KeyUsages,
+	}
+	if len(vo.KeyUsages) == 0 {
+		vo.KeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageAny}
+	}
+	for _, c := range roots {
+		vo.Roots.AddCert(c)
+	}
+	for _, c := range option.Intermediates {
+		vo.Intermediates.AddCert(c)
+	}
+	return leaf.Verify(vo)
+}
+
+func certTemplate(opt CertOptions) (*x509.Certificate, error) {
+	serial := opt.SerialNumber
+	if serial == nil {
+		var err error
+		if serial, err = rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128)); err != nil {
+			return nil, err
+		}
+	}
+	notBefore := opt.NotBefore
+	if notBefore.IsZero() {
+		notBefore = time.Now()
+	}
+	validity := opt.Validity
+	if validity <= 0 {
+		validity = DefaultCertValidity
+	}
+	usage := opt.KeyUsage
+	if usage == 0 {
+		usage = x509.KeyUsageDigitalSignature
+		if opt.IsCA {
+			usage |= x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+		}
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               opt.Subject,
+		DNSNames:              opt.DNSNames,
+		IPAddresses:           opt.IPAddresses,
+		EmailAddresses:        opt.EmailAddresses,
+		NotBefore:             notBefore,
+		NotAfter:              notBefore.Add(validity),
+		KeyUsage:              usage,
+		ExtKeyUsage:           opt.ExtKeyUsage,
+		BasicConstraintsValid: true,
+		IsCA:                  opt.IsCA,
+		SignatureAlgorithm:    x509.SM2WithSM3,
+	}
+	if opt.IsCA && opt.MaxPathLen > 0 {
+		tmpl.MaxPathLen = opt.MaxPathLen
+	}
+	return tmpl, nil
+}
+
+func createCert(tmpl, parent *x509.Certificate, pub *sm2.PublicKey, signer *sm2.PrivateKey) ([]byte, error) {
+	// gmsm 按模板的 SignatureAlgorithm 判断是否需要预先摘要，因此必须是 SM2WithSM3
+	tmpl.SignatureAlgorithm = x509.SM2WithSM3
+	if tmpl.SubjectKeyId == nil {
+		tmpl.SubjectKeyId = subjectKeyID(pub)
+	}
+	der, err := x509.CreateCertificate(tmpl, parent, pub, signer)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: pemCertificate, Bytes: der}), nil
+}
+
+// subjectKeyID 公钥的 SM3 摘要前 20 字节
+func subjectKeyID(pub *sm2.PublicKey) []byte {
+	h := sm3.Sm3Sum(FromSM2Pub(pub))
+	return h[:20]
+}
+
+// asSM2PublicKey gmsm 解析证书时将 SM2 公钥表示为 SM2 曲线上的 *ecdsa.PublicKey
+func asSM2PublicKey(pub interface{}) (*sm2.PublicKey, bool) {
+	switch k := pub.(type) {
+	case *sm2.PublicKey:
+		return k, true
+	case *ecdsa.PublicKey:
+		if k.Curve == sm2.P256Sm2() {
+			return &sm2.PublicKey{Curve: k.Curve, X: k.X, Y: k.Y}, true
+		}
+	}
+	return nil, false
+}
+
+// pemOrDER data 为 PEM 时返回指定类型块的内容，否则视为 DER
+func pemOrDER(data []byte, typ string) ([]byte, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return data, nil
+	}
+	if block.Type != typ {
+		return nil, ErrPEM
+	}
+	return block.Bytes, nil
+}
@@ -0,0 +1,328 @@
+package gcrypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// 信封加密格式（大端序）：
+//
+//	magic "GCE" | version(1) | dataAlg(1) | wrapAlg(1) | len(keyID)(1) | keyID |
+//	len(wrapped)(2) | wrapped | nonce(12) | ciphertext | tag(16)
+//
+// 数据密钥每条消息随机生成，由 KEK 包裹后写入头部；解密时按头部的 keyID 取 KEK，
+// 因此轮换 KEK 后旧数据仍可解密，也可用 EnvelopeRewrap 只换包裹层而不重新加密数据
+const (
+	envelopeVersion = 1
+
+	envDataAES256GCM = 1
+
+	envWrapAESKW = 1
+	envWrapSM2   = 2
+
+	envDataKeyLen = 32
+	envNonceLen   = 12
+)
+
+var envelopeMagic = []byte("GCE")
+
+var (
+	ErrEnvelopeFormat  = errors.New("gcrypto: invalid envelope")
+	ErrEnvelopeVersion = errors.New("gcrypto: unsupported envelope version")
+	ErrDecrypt         = errors.New("gcrypto: decryption failed")
+)
+
+// envelopeHeader 解析后的头部
+type envelopeHeader struct {
+	dataAlg byte
+	wrapAlg byte
+	keyID   string
+	wrapped []byte
+	body    []byte // nonce | ciphertext | tag
+}
+
+// EnvelopeEncrypt 用 kp 的当前密钥做信封加密，aad 为可选的附加认证数据
+func EnvelopeEncrypt(kp KeyProvider, plain, aad []byte) ([]byte, error) {
+	id, err := kp.CurrentKeyID()
+	if err != nil {
+		return nil, err
+	}
+	kek, err := kp.GetKey(id)
+	if err != nil {
+		return nil, err
+	}
+	dek := make([]byte, envDataKeyLen)
+	if _, err := io.ReadFull(rand.Reader, dek); err != nil {
+		return nil, err
+	}
+	wrapAlg, wrapped, err := wrapDataKey(kek, dek)
+	if err != nil {
+		return nil, err
+	}
+	out, err := envelopeHead(envDataAES256GCM, wrapAlg, id, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newAESGCM(dek)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, envNonceLen)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, plain, envelopeAAD(envDataAES256GCM, aad)), nil
+}
+
+// EnvelopeDecrypt 解密 EnvelopeEncrypt 的输出，按头部的 keyID 从 kp 获取 KEK
+func EnvelopeDecrypt(kp KeyProvider, data, aad []byte) ([]byte, error) {
+	h, err := parseEnvelope(data)
+	if err != nil {
+		return nil, err
+	}
+	kek, err := kp.GetKey(h.keyID)
+	if err != nil {
+		return nil, err
+	}
+	dek, err := unwrapDataKey(kek, h.wrapAlg, h.wrapped)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newAESGCM(dek)
+	if err != nil {
+		return nil, err
+	}
+	if len(h.body) < envNonceLen+gcm.Overhead() {
+		return nil, ErrEnvelopeFormat
+	}
+	plain, err := gcm.Open(nil, h.body[:envNonceLen], h.body[envNonceLen:], envelopeAAD(h.dataAlg, aad))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plain, nil
+}
+
+// EnvelopeKeyID 返回密文使用的 KEK ID
+func EnvelopeKeyID(data []byte) (string, error) {
+	h, err := parseEnvelope(data)
+	if err != nil {
+		return "", err
+	}
+	return h.keyID, nil
+}
+
+// EnvelopeRewrap 用 kp 的当前密钥重新包裹数据密钥，数据部分保持不变
+// 密文已使用当前密钥时原样返回
+func EnvelopeRewrap(kp KeyProvider, data []byte) ([]byte, error) {
+	h, err := parseEnvelope(data)
+	if err != nil {
+		return nil, err
+	}
+	id, err := kp.CurrentKeyID()
+	if err != nil {
+		return nil, err
+	}
+	if id == h.keyID {
+		return data, nil
+	}
+	oldKek, err := kp.GetKey(h.keyID)
+	if err != nil {
+		return nil, err
+	}
+	dek, err := unwrapDataKey(oldKek, h.wrapAlg, h.wrapped)
+	if err != nil {
+		return nil, err
+	}
+	kek, err := kp.GetKey(id)
+	if err != nil {
+		return nil, err
+	}
+	wrapAlg, wrapped, err := wrapDataKey(kek, dek)
+	if err != nil {
+		return nil, err
+	}
+	out, err := envelopeHead(h.dataAlg, wrapAlg, id, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	return append(out, h.body...), nil
+}
+
+func envelopeHead(dataAlg, wrapAlg byte, id string, wrapped []byte) ([]byte, error) {
+	if len(id) > 255 || len(wrapped) > 0xffff {
+		return nil, fmt.Errorf("%w: key id or wrapped key too long", ErrEnvelopeFormat)
+	}
+	out := make([]byte, 0, 8+len(id)+len(wrapped)+envNonceLen)
+	out = append(out, envelopeMagic...)
+	out = append(out, envelopeVersion, dataAlg, wrapAlg, byte(len(id)))
+	out = append(out, id...)
+	out = binary.BigEndian.AppendUint16(out, uint16(len(wrapped)))
+	return append(out, wrapped...), nil
+}
+
+// envelopeAAD 数据部分的附加认证数据：固定头部与调用方 aad，不含包裹层以便 Rewrap
+func envelopeAAD(dataAlg byte, aad []byte) []byte {
+	b := append([]byte{}, envelopeMagic...)
+	b = append(b, envelopeVersion, dataAlg)
+	return append(b, aad...)
+}
+
+func parseEnvelope(data []byte) (*envelopeHeader, error) {
+	if len(data) < 7 || !bytes.Equal(data[:3], envelopeMagic) {
+		return nil, ErrEnvelopeFormat
+	}
+	if data[3] != envelopeVersion {
+		return nil, fmt.Errorf("%w: %d", ErrEnvelopeVersion, data[3])
+	}
+	h := &envelopeHeader{dataAlg: data[4], wrapAlg: data[5]}
+	if h.dataAlg != envDataAES256GCM {
+		return nil, fmt.Errorf("%w: data algorithm %d", ErrEnvelopeFormat, h.dataAlg)
+	}
+	p := data[6:]
+	n := int(p[0])
+	if len(p) < 1+n+2 {
+		return nil, ErrEnvelopeFormat
+	}
+	h.keyID = string(p[1 : 1+n])
+	p = p[1+n:]
+	n = int(binary.BigEndian.Uint16(p))
+	if len(p) < 2+n {
+		return nil, ErrEnvelopeFormat
+	}
+	h.wrapped, h.body = p[2:2+n], p[2+n:]
+	return h, nil
+}
+
+func wrapDataKey(kek Key, dek []byte) (byte, []byte, error) {
+	switch kek.Type {
+	case KeyAES:
+		w, err := AESKeyWrap(kek.Secret, dek)
+		return envWrapAESKW, w, err
+	case KeySM2:
+		pub, err := UnmarshalPubkey(kek.Public)
+		if err != nil {
+			return 0, nil, err
+		}
+		w, err := sm2.Encrypt(pub, dek, rand.Reader, sm2.C1C3C2)
+		return envWrapSM2, w, err
+	}
+	return 0, nil, fmt.Errorf("%w: %s", ErrUnsupportedKeyType, kek.Type)
+}
+
+// unwrapDataKey 解开数据密钥，长度不是 envDataKeyLen 时返回 ErrDecrypt
+func unwrapDataKey(kek Key, wrapAlg byte, wrapped []byte) ([]byte, error) {
+	dek, err := unwrapKey(kek, wrapAlg, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	if len(dek) != envDataKeyLen {
+		return nil, ErrDecrypt
+	}
+	return dek, nil
+}
+
+func unwrapKey(kek Key, wrapAlg byte, wrapped []byte) ([]byte, error) {
+	switch {
+	case wrapAlg == envWrapAESKW && kek.Type == KeyAES:
+		return AESKeyUnwrap(kek.Secret, wrapped)
+	case wrapAlg == envWrapSM2 && kek.Type == KeySM2:
+		if len(kek.Secret) == 0 {
+			return nil, fmt.Errorf("%w: %s has no private key", ErrInvalidKey, kek.ID)
+		}
+		priv, err := ToSM2(kek.Secret)
+		if err != nil {
+			return nil, err
+		}
+		if len(wrapped) < 97 {
+			return nil, ErrDecrypt
+		}
+		dek, err := sm2.Decrypt(priv, wrapped, sm2.C1C3C2)
+		if err != nil {
+			return nil, ErrDecrypt
+		}
+		return dek, nil
+	}
+	return nil, fmt.Errorf("%w: key %s (%s) cannot unwrap algorithm %d", ErrInvalidKey, kek.ID, kek.Type, wrapAlg)
+}
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// ---------------- AES Key Wrap (RFC 3394) ----------------
+
+var aesKWIV = []byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+
+var ErrKeyWrap = errors.New("gcrypto: key unwrap failed")
+
+// AESKeyWrap 用 kek 包裹 key（RFC 3394），key 长度需为 8 的倍数且不少于 16 字节
+func AESKeyWrap(kek, key []byte) ([]byte, error) {
+	if len(key)%8 != 0 || len(key) < 16 {
+		return nil, errors.New("gcrypto: key wrap input must be a multiple of 8 bytes, at least 16")
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	n := len(key) / 8
+	out := make([]byte, 8+len(key))
+	copy(out, aesKWIV)
+	copy(out[8:], key)
+	var buf [16]byte
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			copy(buf[:8], out[:8])
+			copy(buf[8:], out[8*i:8*i+8])
+			block.Encrypt(buf[:], buf[:])
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(out[:8], binary.BigEndian.Uint64(buf[:8])^t)
+			copy(out[8*i:], buf[8:])
+		}
+	}
+	return out, nil
+}
+
+// AESKeyUnwrap 解开 AESKeyWrap 的输出并校验完整性
+func AESKeyUnwrap(kek, wrapped []byte) ([]byte, error) {
+	if len(wrapped)%8 != 0 || len(wrapped) < 24 {
+		return nil, ErrKeyWrap
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	n := len(wrapped)/8 - 1
+	a := make([]byte, 8)
+	copy(a, wrapped[:8])
+	r := make([]byte, len(wrapped)-8)
+	copy(r, wrapped[8:])
+	var buf [16]byte
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(buf[:8], binary.BigEndian.Uint64(a)^t)
+			copy(buf[8:], r[8*(i-1):8*i])
+			block.Decrypt(buf[:], buf[:])
+			copy(a, buf[:8])
+			copy(r[8*(i-1):], buf[8:])
+		}
+	}
+	if subtle.ConstantTimeCompare(a, aesKWIV) != 1 {
+		return nil, ErrKeyWrap
+	}
+	return r, nil
+}
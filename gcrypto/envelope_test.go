@@ -0,0 +1,53 @@
+package gcrypto
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestEnvKeyProviderReservesCurrent(t *testing.T) {
+	secret := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+	t.Setenv("GTEST_KEY_k1", "aes:"+secret)
+	t.Setenv("GTEST_KEY_CURRENT", "k1")
+	kp := NewEnvKeyProvider("GTEST_KEY_")
+
+	data, err := EnvelopeEncrypt(kp, []byte("hello"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain, err := EnvelopeDecrypt(kp, data, nil); err != nil || string(plain) != "hello" {
+		t.Fatalf("decrypt = %q, %v", plain, err)
+	}
+	for _, id := range []string{"CURRENT", "current"} {
+		if _, err := kp.GetKey(id); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("GetKey(%q) = %v, want ErrInvalidKey", id, err)
+		}
+	}
+	t.Setenv("GTEST_KEY_CURRENT", "CURRENT")
+	if _, err := kp.CurrentKeyID(); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("CurrentKeyID = %v, want ErrInvalidKey", err)
+	}
+}
+
+func TestEnvelopeRejectsWrongDataKeyLength(t *testing.T) {
+	kek := Key{ID: "k1", Type: KeyAES, Secret: bytes.Repeat([]byte{2}, 32)}
+	kp := &FileKeyProvider{current: "k1", keys: map[string]Key{"k1": kek}}
+	// 包裹层完整，但数据密钥只有 16 字节
+	wrapped, err := AESKeyWrap(kek.Secret, bytes.Repeat([]byte{3}, 16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := envelopeHead(envDataAES256GCM, envWrapAESKW, "k1", wrapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := append(head, make([]byte, envNonceLen+32)...)
+	if _, err := EnvelopeDecrypt(kp, data, nil); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("EnvelopeDecrypt = %v, want ErrDecrypt", err)
+	}
+	if _, err := EnvelopeRewrap(&FileKeyProvider{current: "k2", keys: map[string]Key{"k1": kek, "k2": kek}}, data); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("EnvelopeRewrap = %v, want ErrDecrypt", err)
+	}
+}
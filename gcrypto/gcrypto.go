@@ -10,10 +10,12 @@ import (
 	"crypto/rand"
 	"crypto/sha1"
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"math/big"
 
@@ -41,43 +43,153 @@ func Base64Decode(s string) string {
 func SM3(s string) string      { return SM3Bytes([]byte(s)) }
 func SM3Bytes(b []byte) string { h := sm3.Sm3Sum(b); return hex.EncodeToString(h[:]) }
 
-func SM2Sign(prikey []byte, msg []byte) ([]byte, error) {
+// 流式计算摘要，适用于大文件
+func MD5Reader(r io.Reader) (string, error)    { return hashReader(md5.New(), r) }
+func SHA1Reader(r io.Reader) (string, error)   { return hashReader(sha1.New(), r) }
+func SHA256Reader(r io.Reader) (string, error) { return hashReader(sha256.New(), r) }
+func SM3Reader(r io.Reader) (string, error)    { return hashReader(sm3.New(), r) }
+
+func hashReader(h hash.Hash, r io.Reader) (string, error) {
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// SM2SigEncoding SM2 签名编码
+type SM2SigEncoding int
+
+const (
+	SM2SigASN1 SM2SigEncoding = iota // ASN.1 DER SEQUENCE{r, s}
+	SM2SigRaw                        // r||s，各 32 字节，共 64 字节
+)
+
+// SM2CipherMode SM2 密文格式
+type SM2CipherMode int
+
+const (
+	SM2CipherASN1   SM2CipherMode = iota // ASN.1 DER（C1C3C2 顺序）
+	SM2CipherC1C3C2                      // 04||C1||C3||C2，GM/T 0009 推荐
+	SM2CipherC1C2C3                      // 04||C1||C2||C3，旧标准顺序
+)
+
+// SM2DefaultUID 未指定用户 ID 时使用的默认值
+var SM2DefaultUID = []byte("1234567812345678")
+
+// SM2Options SM2 签名与加密选项，零值与旧版行为一致
+type SM2Options struct {
+	UID         []byte // 签名者用户 ID（参与 Z 值计算），默认 SM2DefaultUID
+	SigEncoding SM2SigEncoding
+	CipherMode  SM2CipherMode
+}
+
+func sm2Option(opt []SM2Options) SM2Options {
+	option := SM2Options{}
+	if len(opt) > 0 {
+		option = opt[0]
+	}
+	if len(option.UID) == 0 {
+		option.UID = SM2DefaultUID
+	}
+	return option
+}
+
+// sm2CipherMinLen 不带 ASN.1 封装的密文最小长度：04||C1(64)||C3(32)
+const sm2CipherMinLen = 1 + 64 + 32
+
+func SM2Sign(prikey []byte, msg []byte, opt ...SM2Options) ([]byte, error) {
 	// 创建sm2对象
 	sm2Prikey, err := ToSM2(prikey)
 	if err != nil {
 		return nil, err
 	}
-	return sm2Prikey.Sign(rand.Reader, msg, nil)
+	return sm2Sign(sm2Prikey, msg, sm2Option(opt))
 }
 
-func SM2Verify(pubkey []byte, msg []byte, sign []byte) bool {
+func SM2Verify(pubkey []byte, msg []byte, sign []byte, opt ...SM2Options) bool {
 	sm2PubKey, err := UnmarshalPubkey(pubkey)
 	if err != nil {
 		return false
 	}
-	return sm2PubKey.Verify(msg, sign)
+	return sm2Verify(sm2PubKey, msg, sign, sm2Option(opt))
+}
+
+func sm2Sign(priv *sm2.PrivateKey, msg []byte, option SM2Options) ([]byte, error) {
+	r, s, err := sm2.Sm2Sign(priv, msg, option.UID, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	if option.SigEncoding == SM2SigRaw {
+		sig := make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+		return sig, nil
+	}
+	return sm2.SignDigitToSignData(r, s)
+}
+
+func sm2Verify(pub *sm2.PublicKey, msg, sign []byte, option SM2Options) bool {
+	var r, s *big.Int
+	if option.SigEncoding == SM2SigRaw {
+		if len(sign) != 64 {
+			return false
+		}
+		r, s = new(big.Int).SetBytes(sign[:32]), new(big.Int).SetBytes(sign[32:])
+	} else {
+		var err error
+		if r, s, err = sm2.SignDataToSignDigit(sign); err != nil {
+			return false
+		}
+	}
+	return sm2.Sm2Verify(pub, msg, option.UID, r, s)
 }
 
 // sm2加密
-func SM2Encrypt(pubkey []byte, msg []byte) ([]byte, error) {
+func SM2Encrypt(pubkey []byte, msg []byte, opt ...SM2Options) ([]byte, error) {
+	option := sm2Option(opt)
 	sm2PubKey, err := UnmarshalPubkey(pubkey)
 	if err != nil {
 		return nil, err
 	}
+	switch option.CipherMode {
+	case SM2CipherC1C3C2:
+		return sm2.Encrypt(sm2PubKey, msg, rand.Reader, sm2.C1C3C2)
+	case SM2CipherC1C2C3:
+		return sm2.Encrypt(sm2PubKey, msg, rand.Reader, sm2.C1C2C3)
+	}
 	return sm2.EncryptAsn1(sm2PubKey, msg, rand.Reader)
 }
 
-func SM2Decrypt(prikey []byte, ciphertext []byte) ([]byte, error) {
+func SM2Decrypt(prikey []byte, ciphertext []byte, opt ...SM2Options) ([]byte, error) {
+	option := sm2Option(opt)
 	sm2Prikey, err := ToSM2(prikey)
 	if err != nil {
 		return nil, err
 	}
-	return sm2.DecryptAsn1(sm2Prikey, ciphertext)
+	if option.CipherMode == SM2CipherASN1 {
+		return sm2.DecryptAsn1(sm2Prikey, ciphertext)
+	}
+	if len(ciphertext) < sm2CipherMinLen || ciphertext[0] != 0x04 {
+		return nil, ErrDecrypt
+	}
+	mode := sm2.C1C3C2
+	if option.CipherMode == SM2CipherC1C2C3 {
+		mode = sm2.C1C2C3
+	}
+	plain, err := sm2.Decrypt(sm2Prikey, ciphertext, mode)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plain, nil
 }
 
 var errInvalidPubkey = errors.New("invalid sm2 public key")
 
+// UnmarshalPubkey 解析未压缩（04||X||Y）或压缩（02/03||X）格式的 SM2 公钥
 func UnmarshalPubkey(pub []byte) (*sm2.PublicKey, error) {
+	if len(pub) == 33 {
+		return decompressSM2Pub(pub)
+	}
 	x, y := elliptic.Unmarshal(sm2.P256Sm2(), pub)
 	if x == nil {
 		return nil, errInvalidPubkey
@@ -142,14 +254,14 @@ func AESCBCDecrypt(key, cipherText []byte) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	if len(cipherText) < block.BlockSize() {
+	if len(cipherText) < 2*block.BlockSize() || len(cipherText)%block.BlockSize() != 0 {
 		return nil, errors.New("cipher too short")
 	}
 	iv := cipherText[:block.BlockSize()]
 	cipherText = cipherText[block.BlockSize():]
 	mode := cipher.NewCBCDecrypter(block, iv)
 	mode.CryptBlocks(cipherText, cipherText)
-	return pkcs7Unpad(cipherText)
+	return pkcs7Unpad(cipherText, block.BlockSize())
 }
 
 // ---------------- 3DES ----------------
@@ -174,14 +286,14 @@ func TripleDESDecrypt(key, cipherText []byte) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	if len(cipherText) < block.BlockSize() {
+	if len(cipherText) < 2*block.BlockSize() || len(cipherText)%block.BlockSize() != 0 {
 		return nil, errors.New("cipher too short")
 	}
 	iv := cipherText[:block.BlockSize()]
 	cipherText = cipherText[block.BlockSize():]
 	mode := cipher.NewCBCDecrypter(block, iv)
 	mode.CryptBlocks(cipherText, cipherText)
-	return pkcs7Unpad(cipherText)
+	return pkcs7Unpad(cipherText, block.BlockSize())
 }
 
 // ---------------- pkcs7 ----------------
@@ -193,13 +305,21 @@ func pkcs7Pad(data []byte, bs int) []byte {
 	return out
 }
 
-func pkcs7Unpad(data []byte) ([]byte, error) {
-	if len(data) == 0 {
-		return nil, errors.New("empty data")
+// pkcs7Unpad 以常量时间校验全部填充字节，出错时不区分具体原因以免成为填充预言机
+func pkcs7Unpad(data []byte, bs int) ([]byte, error) {
+	if len(data) == 0 || len(data)%bs != 0 {
+		return nil, errors.New("invalid pad")
+	}
+	pad := data[len(data)-1]
+	good := subtle.ConstantTimeLessOrEq(1, int(pad)) & subtle.ConstantTimeLessOrEq(int(pad), bs)
+	// 检查最后 bs 个字节中位于填充范围内的字节都等于 pad
+	for i := 1; i <= bs; i++ {
+		inPad := subtle.ConstantTimeLessOrEq(i, int(pad))
+		eq := subtle.ConstantTimeByteEq(data[len(data)-i], pad)
+		good &= subtle.ConstantTimeSelect(inPad, eq, 1)
 	}
-	pad := int(data[len(data)-1])
-	if pad > len(data) {
+	if good != 1 {
 		return nil, errors.New("invalid pad")
 	}
-	return data[:len(data)-pad], nil
+	return data[:len(data)-int(pad)], nil
 }
@@ -0,0 +1,181 @@
+package gcrypto
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// 自定义用户 ID 的已知答案，由 emmansun/gmsm 独立生成
+func TestSM2CustomUIDKnownAnswer(t *testing.T) {
+	priv, err := ToSM2(unhex(t, "3945208f7b2144b13f36e38ac6d39f95889393692860b51a42fb81ef4df7c5b8"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := FromSM2Pub(&priv.PublicKey)
+	if want := unhex(t, "0409f9df311e5421a150dd7d161e4bc5c672179fad1833fc076bb08ff356f35020ccea490ce26775a52dc6ea718cc1aa600aed05fbf35e084a6632f6072da9ad13"); !bytes.Equal(pub, want) {
+		t.Fatalf("public key = %x", pub)
+	}
+	uid := []byte("ALICE123@YAHOO.COM")
+	za, err := sm2.ZA(&priv.PublicKey, uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := unhex(t, "26db4bc1839bd22e97e1dab667ec5e0a730d5e16521398b4435c576a93afd7ed"); !bytes.Equal(za, want) {
+		t.Fatalf("ZA = %x", za)
+	}
+
+	msg := []byte("message digest")
+	sig := unhex(t, "3045022100a70162e9b9236a5ce956ac1532ad63e023a17e48aaa036f6b86a07d9d224a023022042111267780c9d0758e42e8750b3e1781395752db9b966247805c9e822443e91")
+	if !SM2Verify(pub, msg, sig, SM2Options{UID: uid}) {
+		t.Fatal("known signature rejected")
+	}
+	if SM2Verify(pub, msg, sig) {
+		t.Fatal("signature accepted with the default UID")
+	}
+	if SM2Verify(pub, []byte("message digesT"), sig, SM2Options{UID: uid}) {
+		t.Fatal("signature accepted for another message")
+	}
+}
+
+func TestSM2RawSignature(t *testing.T) {
+	priv, err := GenerateSM2Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, pub := priv.D.FillBytes(make([]byte, 32)), FromSM2Pub(&priv.PublicKey)
+	msg := []byte("hello")
+	raw := SM2Options{UID: []byte("Alice"), SigEncoding: SM2SigRaw}
+
+	sig, err := SM2Sign(d, msg, raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sig) != 64 {
+		t.Fatalf("raw signature is %d bytes, want 64", len(sig))
+	}
+	if !SM2Verify(pub, msg, sig, raw) {
+		t.Fatal("raw signature rejected")
+	}
+	if SM2Verify(pub, msg, sig, SM2Options{UID: raw.UID}) {
+		t.Fatal("raw signature accepted as ASN.1")
+	}
+	if SM2Verify(pub, msg, sig[:63], raw) {
+		t.Fatal("truncated raw signature accepted")
+	}
+
+	// 同一签名的两种编码可以互相转换
+	der, err := sm2.SignDigitToSignData(new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !SM2Verify(pub, msg, der, SM2Options{UID: raw.UID}) {
+		t.Fatal("re-encoded ASN.1 signature rejected")
+	}
+	asn1Sig, err := SM2Sign(d, msg, SM2Options{UID: raw.UID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if SM2Verify(pub, msg, asn1Sig, raw) {
+		t.Fatal("ASN.1 signature accepted as raw")
+	}
+}
+
+func TestSM2CipherModes(t *testing.T) {
+	priv, err := GenerateSM2Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, pub := priv.D.FillBytes(make([]byte, 32)), FromSM2Pub(&priv.PublicKey)
+	msg := []byte("the quick brown fox")
+	modes := []SM2CipherMode{SM2CipherASN1, SM2CipherC1C3C2, SM2CipherC1C2C3}
+	for _, mode := range modes {
+		ct, err := SM2Encrypt(pub, msg, SM2Options{CipherMode: mode})
+		if err != nil {
+			t.Fatal(err)
+		}
+		plain, err := SM2Decrypt(d, ct, SM2Options{CipherMode: mode})
+		if err != nil || !bytes.Equal(plain, msg) {
+			t.Fatalf("mode %d: decrypt = %q, %v", mode, plain, err)
+		}
+		for _, other := range modes {
+			if other == mode {
+				continue
+			}
+			if plain, err := SM2Decrypt(d, ct, SM2Options{CipherMode: other}); err == nil && bytes.Equal(plain, msg) {
+				t.Errorf("mode %d ciphertext decrypted as mode %d", mode, other)
+			}
+		}
+	}
+
+	// 两种拼接顺序的区别只在 C2 与 C3 的位置
+	ct, err := SM2Encrypt(pub, msg, SM2Options{CipherMode: SM2CipherC1C3C2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c1, c3, c2 := ct[:65], ct[65:97], ct[97:]
+	swapped := concat(c1, c2, c3)
+	plain, err := SM2Decrypt(d, swapped, SM2Options{CipherMode: SM2CipherC1C2C3})
+	if err != nil || !bytes.Equal(plain, msg) {
+		t.Fatalf("C1C2C3 decrypt of reordered ciphertext = %q, %v", plain, err)
+	}
+	if _, err := SM2Decrypt(d, ct[:sm2CipherMinLen-1], SM2Options{CipherMode: SM2CipherC1C3C2}); err != ErrDecrypt {
+		t.Fatalf("short ciphertext: %v", err)
+	}
+}
+
+func TestPKCS7Unpad(t *testing.T) {
+	const bs = 16
+	for n := 0; n <= 2*bs; n++ {
+		data := bytes.Repeat([]byte{'a'}, n)
+		padded := pkcs7Pad(data, bs)
+		if len(padded)%bs != 0 || len(padded) <= n {
+			t.Fatalf("pkcs7Pad(%d bytes) = %d bytes", n, len(padded))
+		}
+		got, err := pkcs7Unpad(padded, bs)
+		if err != nil || !bytes.Equal(got, data) {
+			t.Fatalf("%d bytes: pkcs7Unpad = %q, %v", n, got, err)
+		}
+	}
+	block := func(tail ...byte) []byte {
+		return append(bytes.Repeat([]byte{'a'}, bs-len(tail)), tail...)
+	}
+	for name, data := range map[string][]byte{
+		"empty":               nil,
+		"not block aligned":   append(block(1), 1),
+		"zero pad":            block(0),
+		"pad exceeds block":   block(17),
+		"pad of 255":          block(0xff),
+		"mismatched byte":     block(3, 2, 3),
+		"mismatched first":    block(3, 4, 4, 4),
+		"full block mismatch": append([]byte{15}, bytes.Repeat([]byte{16}, bs-1)...),
+	} {
+		if _, err := pkcs7Unpad(data, bs); err == nil || err.Error() != "invalid pad" {
+			t.Errorf("%s: pkcs7Unpad error = %v", name, err)
+		}
+	}
+	// 填充之外的字节不参与校验
+	if got, err := pkcs7Unpad(block(9, 2, 2), bs); err != nil || len(got) != bs-2 {
+		t.Errorf("pkcs7Unpad = %d bytes, %v", len(got), err)
+	}
+}
+
+func TestAESCBC(t *testing.T) {
+	key := make([]byte, 16)
+	for _, plain := range [][]byte{nil, []byte("hello"), bytes.Repeat([]byte{1}, 32)} {
+		data, err := AESCBCEncrypt(key, plain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := AESCBCDecrypt(key, data); err != nil || !bytes.Equal(got, plain) {
+			t.Errorf("AESCBCDecrypt = %q, %v", got, err)
+		}
+		// 翻转前一分组（或 IV）的末字节，使解密后的填充字节大于分组长度
+		data[len(data)-17] ^= 0x80
+		if _, err := AESCBCDecrypt(key, data); err == nil {
+			t.Error("tampered padding accepted")
+		}
+	}
+}
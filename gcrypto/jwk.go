@@ -0,0 +1,274 @@
+package gcrypto
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+var ErrJWK = errors.New("gcrypto: invalid jwk")
+
+// JWK JSON Web Key（RFC 7517），字段值为 base64url 无填充编码；
+// SM2 密钥表示为 kty "EC"、crv "SM2"
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Crv string `json:"crv,omitempty"`
+
+	X string `json:"x,omitempty"`
+	Y string `json:"y,omitempty"`
+	D string `json:"d,omitempty"`
+
+	N  string `json:"n,omitempty"`
+	E  string `json:"e,omitempty"`
+	P  string `json:"p,omitempty"`
+	Q  string `json:"q,omitempty"`
+	DP string `json:"dp,omitempty"`
+	DQ string `json:"dq,omitempty"`
+	QI string `json:"qi,omitempty"`
+}
+
+// NewJWK 由公钥或私钥创建 JWK，私钥会导出私有部分，Alg 按密钥推断（RSA 为 PS256）
+func NewJWK(key interface{}, kid string) (*JWK, error) {
+	j := &JWK{Kid: kid}
+	switch k := key.(type) {
+	case *sm2.PrivateKey:
+		j.setEC("SM2", k.Curve, k.X, k.Y)
+		j.D = b64(ecCoord(k.D, k.Curve))
+	case *sm2.PublicKey:
+		j.setEC("SM2", k.Curve, k.X, k.Y)
+	case *ecdsa.PrivateKey:
+		if err := j.setECDSA(&k.PublicKey); err != nil {
+			return nil, err
+		}
+		j.D = b64(ecCoord(k.D, k.Curve))
+	case *ecdsa.PublicKey:
+		if err := j.setECDSA(k); err != nil {
+			return nil, err
+		}
+	case ed25519.PrivateKey:
+		j.Kty, j.Crv = "OKP", "Ed25519"
+		j.X, j.D = b64(k.Public().(ed25519.PublicKey)), b64(k.Seed())
+	case ed25519.PublicKey:
+		j.Kty, j.Crv, j.X = "OKP", "Ed25519", b64(k)
+	case *rsa.PrivateKey:
+		j.setRSA(&k.PublicKey)
+		if len(k.Primes) != 2 {
+			return nil, fmt.Errorf("%w: multi-prime rsa key", ErrJWK)
+		}
+		k.Precompute()
+		j.D, j.P, j.Q = b64(k.D.Bytes()), b64(k.Primes[0].Bytes()), b64(k.Primes[1].Bytes())
+		j.DP, j.DQ, j.QI = b64(k.Precomputed.Dp.Bytes()), b64(k.Precomputed.Dq.Bytes()), b64(k.Precomputed.Qinv.Bytes())
+	case *rsa.PublicKey:
+		j.setRSA(k)
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedKeyType, key)
+	}
+	if v, err := NewVerifier(publicOf(key)); err == nil {
+		j.Alg = string(v.Algorithm())
+	}
+	return j, nil
+}
+
+// IsPrivate 是否包含私钥
+func (j *JWK) IsPrivate() bool { return j.D != "" }
+
+// Public 去掉私有部分的副本
+func (j *JWK) Public() *JWK {
+	p := *j
+	p.D, p.P, p.Q, p.DP, p.DQ, p.QI = "", "", "", "", "", ""
+	return &p
+}
+
+// PublicKey 返回公钥：*sm2.PublicKey、*ecdsa.PublicKey、ed25519.PublicKey 或 *rsa.PublicKey
+func (j *JWK) PublicKey() (crypto.PublicKey, error) {
+	switch j.Kty {
+	case "EC":
+		curve, err := jwkCurve(j.Crv)
+		if err != nil {
+			return nil, err
+		}
+		x, err := b64Int(j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := b64Int(j.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("%w: point not on curve", ErrJWK)
+		}
+		if curve == sm2.P256Sm2() {
+			return &sm2.PublicKey{Curve: curve, X: x, Y: y}, nil
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if j.Crv != "Ed25519" {
+			return nil, fmt.Errorf("%w: unsupported curve %q", ErrJWK, j.Crv)
+		}
+		x, err := b64Decode(j.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("%w: invalid ed25519 key", ErrJWK)
+		}
+		return ed25519.PublicKey(x), nil
+	case "RSA":
+		n, err := b64Int(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := b64Int(j.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("%w: invalid rsa exponent", ErrJWK)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	}
+	return nil, fmt.Errorf("%w: unsupported kty %q", ErrJWK, j.Kty)
+}
+
+// PrivateKey 返回私钥：*sm2.PrivateKey、*ecdsa.PrivateKey、ed25519.PrivateKey 或 *rsa.PrivateKey
+func (j *JWK) PrivateKey() (crypto.PrivateKey, error) {
+	if !j.IsPrivate() {
+		return nil, fmt.Errorf("%w: no private key", ErrJWK)
+	}
+	pub, err := j.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	switch k := pub.(type) {
+	case *sm2.PublicKey:
+		d, err := b64Decode(j.D)
+		if err != nil {
+			return nil, err
+		}
+		priv, err := ToSM2(d)
+		if err != nil || priv.X.Cmp(k.X) != 0 || priv.Y.Cmp(k.Y) != 0 {
+			return nil, fmt.Errorf("%w: private key does not match public key", ErrJWK)
+		}
+		return priv, nil
+	case *ecdsa.PublicKey:
+		d, err := b64Int(j.D)
+		if err != nil {
+			return nil, err
+		}
+		if d.Sign() <= 0 || d.Cmp(k.Curve.Params().N) >= 0 {
+			return nil, fmt.Errorf("%w: invalid ec private key", ErrJWK)
+		}
+		x, y := k.Curve.ScalarBaseMult(ecCoord(d, k.Curve))
+		if x.Cmp(k.X) != 0 || y.Cmp(k.Y) != 0 {
+			return nil, fmt.Errorf("%w: private key does not match public key", ErrJWK)
+		}
+		return &ecdsa.PrivateKey{PublicKey: *k, D: d}, nil
+	case ed25519.PublicKey:
+		seed, err := b64Decode(j.D)
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("%w: invalid ed25519 seed", ErrJWK)
+		}
+		priv := ed25519.NewKeyFromSeed(seed)
+		if !priv.Public().(ed25519.PublicKey).Equal(k) {
+			return nil, fmt.Errorf("%w: private key does not match public key", ErrJWK)
+		}
+		return priv, nil
+	case *rsa.PublicKey:
+		var ints [3]*big.Int
+		for i, s := range []string{j.D, j.P, j.Q} {
+			if ints[i], err = b64Int(s); err != nil {
+				return nil, fmt.Errorf("%w: rsa private key requires d, p and q", ErrJWK)
+			}
+		}
+		priv := &rsa.PrivateKey{PublicKey: *k, D: ints[0], Primes: []*big.Int{ints[1], ints[2]}}
+		if err := priv.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrJWK, err)
+		}
+		priv.Precompute()
+		return priv, nil
+	}
+	return nil, fmt.Errorf("%w: unsupported kty %q", ErrJWK, j.Kty)
+}
+
+func (j *JWK) setEC(crv string, curve elliptic.Curve, x, y *big.Int) {
+	j.Kty, j.Crv = "EC", crv
+	j.X, j.Y = b64(ecCoord(x, curve)), b64(ecCoord(y, curve))
+}
+
+func (j *JWK) setECDSA(k *ecdsa.PublicKey) error {
+	switch k.Curve {
+	case elliptic.P256():
+		j.setEC("P-256", k.Curve, k.X, k.Y)
+	case elliptic.P384():
+		j.setEC("P-384", k.Curve, k.X, k.Y)
+	case elliptic.P521():
+		j.setEC("P-521", k.Curve, k.X, k.Y)
+	case sm2.P256Sm2():
+		j.setEC("SM2", k.Curve, k.X, k.Y)
+	default:
+		return fmt.Errorf("%w: curve %s", ErrUnsupportedKeyType, k.Curve.Params().Name)
+	}
+	return nil
+}
+
+func (j *JWK) setRSA(k *rsa.PublicKey) {
+	j.Kty = "RSA"
+	j.N, j.E = b64(k.N.Bytes()), b64(big.NewInt(int64(k.E)).Bytes())
+}
+
+// publicOf 私钥返回对应公钥，公钥原样返回
+func publicOf(key interface{}) crypto.PublicKey {
+	if k, ok := key.(interface{ Public() crypto.PublicKey }); ok {
+		return k.Public()
+	}
+	return key
+}
+
+func jwkCurve(crv string) (elliptic.Curve, error) {
+	switch crv {
+	case "P-256":
+		return elliptic.P256(), nil
+	case "P-384":
+		return elliptic.P384(), nil
+	case "P-521":
+		return elliptic.P521(), nil
+	case "SM2":
+		return sm2.P256Sm2(), nil
+	}
+	return nil, fmt.Errorf("%w: unsupported curve %q", ErrJWK, crv)
+}
+
+// ecCoord 按曲线长度定长编码坐标
+func ecCoord(v *big.Int, curve elliptic.Curve) []byte {
+	return v.FillBytes(make([]byte, (curve.Params().BitSize+7)/8))
+}
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+func b64Decode(s string) ([]byte, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrJWK, err)
+	}
+	return b, nil
+}
+
+func b64Int(s string) (*big.Int, error) {
+	if s == "" {
+		return nil, fmt.Errorf("%w: missing field", ErrJWK)
+	}
+	b, err := b64Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,194 @@
+package gcrypto
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrKeyNotFound        = errors.New("gcrypto: key not found")
+	ErrNoCurrentKey       = errors.New("gcrypto: no current key")
+	ErrInvalidKey         = errors.New("gcrypto: invalid key")
+	ErrUnsupportedKeyType = errors.New("gcrypto: unsupported key type")
+)
+
+// KeyType 密钥类型
+type KeyType string
+
+const (
+	KeyAES KeyType = "aes" // 对称密钥，16/24/32 字节，用 AES-KW 包裹数据密钥
+	KeySM2 KeyType = "sm2" // SM2 密钥对，用 SM2 公钥加密数据密钥
+)
+
+// Key 密钥加密密钥（KEK）
+type Key struct {
+	ID     string  `json:"id"`
+	Type   KeyType `json:"type"`
+	Secret []byte  `json:"secret,omitempty"` // AES 密钥或 SM2 私钥 D（32 字节）
+	Public []byte  `json:"public,omitempty"` // SM2 公钥 04||X||Y，缺省时由 Secret 推导；只有公钥时只能加密
+}
+
+// validate 校验并补全 SM2 公钥
+func (k *Key) validate() error {
+	switch k.Type {
+	case KeyAES:
+		switch len(k.Secret) {
+		case 16, 24, 32:
+			return nil
+		}
+		return fmt.Errorf("%w: %s: aes key must be 16, 24 or 32 bytes", ErrInvalidKey, k.ID)
+	case KeySM2:
+		if len(k.Secret) > 0 {
+			priv, err := ToSM2(k.Secret)
+			if err != nil {
+				return fmt.Errorf("%w: %s: %v", ErrInvalidKey, k.ID, err)
+			}
+			if len(k.Public) == 0 {
+				k.Public = FromSM2Pub(&priv.PublicKey)
+			}
+		}
+		if _, err := UnmarshalPubkey(k.Public); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidKey, k.ID, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrUnsupportedKeyType, k.Type)
+}
+
+// KeyProvider 密钥提供者（KMS），按 ID 提供 KEK
+type KeyProvider interface {
+	// GetKey 获取指定 ID 的密钥，用于解密历史数据
+	GetKey(id string) (Key, error)
+	// CurrentKeyID 当前用于加密的密钥 ID，轮换密钥时修改它即可
+	CurrentKeyID() (string, error)
+}
+
+// FileKeyProvider 从 JSON 文件读取密钥：
+//
+//	{"current": "k2", "keys": [
+//	  {"id": "k1", "type": "aes", "secret": "<base64>"},
+//	  {"id": "k2", "type": "sm2", "secret": "<base64 D>"}]}
+type FileKeyProvider struct {
+	path    string
+	mu      sync.RWMutex
+	current string
+	keys    map[string]Key
+}
+
+// NewFileKeyProvider 加载 path 处的密钥文件
+func NewFileKeyProvider(path string) (*FileKeyProvider, error) {
+	p := &FileKeyProvider{path: path}
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Reload 重新读取密钥文件，用于不停机轮换
+func (p *FileKeyProvider) Reload() error {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return err
+	}
+	var doc struct {
+		Current string `json:"current"`
+		Keys    []Key  `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("gcrypto: parse %s: %w", p.path, err)
+	}
+	keys := make(map[string]Key, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if err := k.validate(); err != nil {
+			return err
+		}
+		keys[k.ID] = k
+	}
+	if _, ok := keys[doc.Current]; doc.Current != "" && !ok {
+		return fmt.Errorf("%w: current %s", ErrKeyNotFound, doc.Current)
+	}
+	p.mu.Lock()
+	p.current, p.keys = doc.Current, keys
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *FileKeyProvider) GetKey(id string) (Key, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	k, ok := p.keys[id]
+	if !ok {
+		return Key{}, fmt.Errorf("%w: %s", ErrKeyNotFound, id)
+	}
+	return k, nil
+}
+
+func (p *FileKeyProvider) CurrentKeyID() (string, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.current == "" {
+		return "", ErrNoCurrentKey
+	}
+	return p.current, nil
+}
+
+// EnvKeyProvider 从环境变量读取密钥，每次调用时读取：
+// <Prefix><ID>=aes:<base64> 或 sm2:<base64 D>，<Prefix>CURRENT=<ID>
+// CURRENT 保留给当前密钥指针，不能用作密钥 ID
+type EnvKeyProvider struct {
+	Prefix string
+}
+
+// DefaultKeyEnvPrefix 默认环境变量前缀
+const DefaultKeyEnvPrefix = "GCRYPTO_KEY_"
+
+// envCurrentKey 存放当前密钥 ID 的环境变量后缀
+const envCurrentKey = "CURRENT"
+
+// NewEnvKeyProvider 创建环境变量密钥提供者，prefix 为空时使用 DefaultKeyEnvPrefix
+func NewEnvKeyProvider(prefix string) *EnvKeyProvider {
+	if prefix == "" {
+		prefix = DefaultKeyEnvPrefix
+	}
+	return &EnvKeyProvider{Prefix: prefix}
+}
+
+func (p *EnvKeyProvider) GetKey(id string) (Key, error) {
+	// Windows 环境变量名不区分大小写
+	if strings.EqualFold(id, envCurrentKey) {
+		return Key{}, fmt.Errorf("%w: key id %s is reserved", ErrInvalidKey, id)
+	}
+	v, ok := os.LookupEnv(p.Prefix + id)
+	if !ok || v == "" {
+		return Key{}, fmt.Errorf("%w: %s", ErrKeyNotFound, id)
+	}
+	typ, enc, ok := strings.Cut(v, ":")
+	if !ok {
+		typ, enc = string(KeyAES), v
+	}
+	secret, err := base64.StdEncoding.DecodeString(enc)
+	if err != nil {
+		return Key{}, fmt.Errorf("%w: %s: %v", ErrInvalidKey, id, err)
+	}
+	k := Key{ID: id, Type: KeyType(strings.ToLower(typ)), Secret: secret}
+	if err := k.validate(); err != nil {
+		return Key{}, err
+	}
+	return k, nil
+}
+
+func (p *EnvKeyProvider) CurrentKeyID() (string, error) {
+	id := os.Getenv(p.Prefix + envCurrentKey)
+	if id == "" {
+		return "", ErrNoCurrentKey
+	}
+	if strings.EqualFold(id, envCurrentKey) {
+		return "", fmt.Errorf("%w: key id %s is reserved", ErrInvalidKey, id)
+	}
+	return id, nil
+}
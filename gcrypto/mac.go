@@ -0,0 +1,103 @@
+package gcrypto
+
+import (
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"fmt"
+	"hash"
+
+	"github.com/tjfoc/gmsm/sm3"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// HashAlg HMAC、HKDF 与 PBKDF2 使用的摘要算法
+type HashAlg string
+
+const (
+	HashSHA256 HashAlg = "sha256"
+	HashSHA384 HashAlg = "sha384"
+	HashSHA512 HashAlg = "sha512"
+	HashSM3    HashAlg = "sm3"
+)
+
+func (a HashAlg) newFunc() (func() hash.Hash, error) {
+	switch a {
+	case HashSHA256:
+		return sha256.New, nil
+	case HashSHA384:
+		return sha512.New384, nil
+	case HashSHA512:
+		return sha512.New, nil
+	case HashSM3:
+		return newSM3, nil
+	}
+	return nil, fmt.Errorf("gcrypto: unsupported hash %q", a)
+}
+
+// sm3Hash tjfoc 的 Sum(b) 把 b 写入摘要并只返回摘要，不符合 hash.Hash 的追加约定，
+// crypto/hmac 与 pbkdf2 依赖追加语义，这里改为标准行为
+type sm3Hash struct{ *sm3.SM3 }
+
+func (h sm3Hash) Sum(b []byte) []byte { return append(b, h.SM3.Sum(nil)...) }
+
+func newSM3() hash.Hash { return sm3Hash{sm3.New().(*sm3.SM3)} }
+
+// HMAC 计算 msg 的 HMAC
+func HMAC(alg HashAlg, key, msg []byte) ([]byte, error) {
+	h, err := alg.newFunc()
+	if err != nil {
+		return nil, err
+	}
+	m := hmac.New(h, key)
+	m.Write(msg)
+	return m.Sum(nil), nil
+}
+
+// HMACVerify 以常量时间校验 mac，算法不支持时返回 false
+func HMACVerify(alg HashAlg, key, msg, mac []byte) bool {
+	expected, err := HMAC(alg, key, msg)
+	return err == nil && hmac.Equal(expected, mac)
+}
+
+func HMACSHA256(key, msg []byte) []byte { m, _ := HMAC(HashSHA256, key, msg); return m }
+func HMACSHA384(key, msg []byte) []byte { m, _ := HMAC(HashSHA384, key, msg); return m }
+func HMACSHA512(key, msg []byte) []byte { m, _ := HMAC(HashSHA512, key, msg); return m }
+func HMACSM3(key, msg []byte) []byte    { m, _ := HMAC(HashSM3, key, msg); return m }
+
+// ConstantTimeEqual 常量时间比较，用于校验 MAC、令牌等秘密值
+func ConstantTimeEqual(a, b []byte) bool {
+	return subtle.ConstantTimeCompare(a, b) == 1
+}
+
+// HKDF 由高熵的 secret 派生 length 字节密钥（RFC 5869），不适用于口令
+func HKDF(alg HashAlg, secret, salt []byte, info string, length int) ([]byte, error) {
+	h, err := alg.newFunc()
+	if err != nil {
+		return nil, err
+	}
+	return hkdf.Key(h, secret, salt, info, length)
+}
+
+// PBKDF2 由口令派生 keyLen 字节密钥（RFC 8018）
+func PBKDF2(alg HashAlg, password, salt []byte, iter, keyLen int) ([]byte, error) {
+	h, err := alg.newFunc()
+	if err != nil {
+		return nil, err
+	}
+	return pbkdf2.Key(h, string(password), salt, iter, keyLen)
+}
+
+// Scrypt 由口令派生 keyLen 字节密钥，n 为 2 的幂（RFC 7914）
+func Scrypt(password, salt []byte, n, r, p, keyLen int) ([]byte, error) {
+	return scrypt.Key(password, salt, n, r, p, keyLen)
+}
+
+// Argon2id 由口令派生 keyLen 字节密钥（RFC 9106），memory 单位为 KiB
+func Argon2id(password, salt []byte, time, memory uint32, threads uint8, keyLen uint32) []byte {
+	return argon2.IDKey(password, salt, time, memory, threads, keyLen)
+}
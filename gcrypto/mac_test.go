@@ -0,0 +1,128 @@
+package gcrypto
+
+import (
+	"bytes"
+	"testing"
+)
+
+// RFC 4231 测试用例 1、2、6
+func TestHMACRFC4231(t *testing.T) {
+	cases := []struct {
+		key, data              []byte
+		sha256, sha384, sha512 string
+	}{
+		{
+			bytes.Repeat([]byte{0x0b}, 20), []byte("Hi There"),
+			"b0344c61d8db38535ca8afceaf0bf12b881dc200c9833da726e9376c2e32cff7",
+			"afd03944d84895626b0825f4ab46907f15f9dadbe4101ec682aa034c7cebc59cfaea9ea9076ede7f4af152e8b2fa9cb6",
+			"87aa7cdea5ef619d4ff0b4241a1d6cb02379f4e2ce4ec2787ad0b30545e17cdedaa833b7d6b8a702038b274eaea3f4e4be9d914eeb61f1702e696c203a126854",
+		},
+		{
+			[]byte("Jefe"), []byte("what do ya want for nothing?"),
+			"5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
+			"af45d2e376484031617f78d2b58a6b1b9c7ef464f5a01b47e42ec3736322445e8e2240ca5e69e2c78b3239ecfab21649",
+			"164b7a7bfcf819e2e395fbe73b56e0a387bd64222e831fd610270cd7ea2505549758bf75c05a994a6d034f65f8f0e6fdcaeab1a34d4a6b4b636e070a38bce737",
+		},
+		{
+			bytes.Repeat([]byte{0xaa}, 131), []byte("Test Using Larger Than Block-Size Key - Hash Key First"),
+			"60e431591ee0b67f0d8a26aacbf5b77f8e0bc6213728c5140546040f0ee37f54",
+			"4ece084485813e9088d2c63a041bc5b44f9ef1012a2b588f3cd11f05033ac4c60c2ef6ab4030fe8296248df163f44952",
+			"80b24263c7c1a3ebb71493c1dd7be8b49b46d1f41b4aeec1121b013783f8f3526b56d037e05f2598bd0fd2215d6a1e5295e64f73f63f0aec8b915a985d786598",
+		},
+	}
+	for i, c := range cases {
+		for _, h := range []struct {
+			alg  HashAlg
+			want string
+		}{{HashSHA256, c.sha256}, {HashSHA384, c.sha384}, {HashSHA512, c.sha512}} {
+			mac, err := HMAC(h.alg, c.key, c.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := unhex(t, h.want)
+			if !bytes.Equal(mac, want) {
+				t.Errorf("case %d %s: got %x", i+1, h.alg, mac)
+			}
+			if !HMACVerify(h.alg, c.key, c.data, want) || HMACVerify(h.alg, c.key, c.data, want[1:]) {
+				t.Errorf("case %d %s: HMACVerify mismatch", i+1, h.alg)
+			}
+		}
+	}
+	if !bytes.Equal(HMACSHA256(cases[1].key, cases[1].data), unhex(t, cases[1].sha256)) {
+		t.Error("HMACSHA256 mismatch")
+	}
+	if _, err := HMAC("md5", nil, nil); err == nil {
+		t.Error("unsupported hash accepted")
+	}
+}
+
+// HMAC-SM3 与 PBKDF2-SM3 的已知答案由 emmansun/gmsm 的 SM3 配合 crypto/hmac 独立生成
+func TestSM3KnownAnswers(t *testing.T) {
+	mac := HMACSM3([]byte("key"), []byte("The quick brown fox jumps over the lazy dog"))
+	if want := unhex(t, "bd4a34077888162b210645b8ebf74b9af357303789357a27c7fc457244ebd398"); !bytes.Equal(mac, want) {
+		t.Errorf("HMAC-SM3 = %x", mac)
+	}
+	// 超过一个摘要长度，需要多个 PBKDF2 块
+	key, err := PBKDF2(HashSM3, []byte("password"), []byte("salt"), 2, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := unhex(t, "fee723a2bc966e11dffb66133f4e8df577383c78ade30e3298edbd3e54ed85b7650006f9e15d3798b131bdb5106d5dddb15c00572aea1830e37a534acaa6f917"); !bytes.Equal(key, want) {
+		t.Errorf("PBKDF2-SM3 = %x", key)
+	}
+}
+
+// RFC 5869 测试用例 1、2、3
+func TestHKDFRFC5869(t *testing.T) {
+	seq := func(from, to int) []byte {
+		b := make([]byte, 0, to-from+1)
+		for i := from; i <= to; i++ {
+			b = append(b, byte(i))
+		}
+		return b
+	}
+	for i, c := range []struct {
+		ikm, salt, info []byte
+		length          int
+		okm             string
+	}{
+		{
+			bytes.Repeat([]byte{0x0b}, 22), seq(0x00, 0x0c), seq(0xf0, 0xf9), 42,
+			"3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865",
+		},
+		{
+			seq(0x00, 0x4f), seq(0x60, 0xaf), seq(0xb0, 0xff), 82,
+			"b11e398dc80327a1c8e7f78c596a49344f012eda2d4efad8a050cc4c19afa97c59045a99cac7827271cb41c65e590e09da3275600c2f09b8367793a9aca3db71cc30c58179ec3e87c14c01d5c1f3434f1d87",
+		},
+		{
+			bytes.Repeat([]byte{0x0b}, 22), nil, nil, 42,
+			"8da4e775a563c18f715f802a063c5a31b8a11f5c5ee1879ec3454e5f3c738d2d9d201395faa4b61a96c8",
+		},
+	} {
+		okm, err := HKDF(HashSHA256, c.ikm, c.salt, string(c.info), c.length)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(okm, unhex(t, c.okm)) {
+			t.Errorf("case %d: got %x", i+1, okm)
+		}
+	}
+}
+
+func TestPBKDF2AndScryptVectors(t *testing.T) {
+	// RFC 7914 第 11 节
+	key, err := PBKDF2(HashSHA256, []byte("passwd"), []byte("salt"), 1, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := unhex(t, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"); !bytes.Equal(key, want) {
+		t.Errorf("PBKDF2-SHA256 = %x", key)
+	}
+	key, err = Scrypt([]byte("password"), []byte("NaCl"), 1024, 8, 16, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := unhex(t, "fdbabe1c9d3472007856e7190d01e9fe7c6ad7cbc8237830e77376634b3731622eaf30d92e22a3886ff109279d9830dac727afb94a83ee6d8360cbdfa2cc0640"); !bytes.Equal(key, want) {
+		t.Errorf("scrypt = %x", key)
+	}
+}
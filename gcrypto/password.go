@@ -0,0 +1,332 @@
+package gcrypto
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordAlg 口令哈希算法
+type PasswordAlg string
+
+const (
+	PasswordArgon2id     PasswordAlg = "argon2id"
+	PasswordScrypt       PasswordAlg = "scrypt"
+	PasswordBcrypt       PasswordAlg = "bcrypt"
+	PasswordPBKDF2SHA256 PasswordAlg = "pbkdf2-sha256"
+	PasswordPBKDF2SM3    PasswordAlg = "pbkdf2-sm3"
+)
+
+// 口令哈希默认参数
+const (
+	DefaultArgon2Time    = 3
+	DefaultArgon2Memory  = 64 << 10 // KiB
+	DefaultArgon2Threads = 4
+	DefaultScryptLogN    = 15
+	DefaultScryptR       = 8
+	DefaultScryptP       = 1
+	DefaultBcryptCost    = 12
+	DefaultPBKDF2Iter    = 600000
+	DefaultSaltLen       = 16
+	DefaultHashLen       = 32
+)
+
+// 口令哈希参数上限，超出时 HashPassword 与 VerifyPassword 返回 ErrPasswordHash，
+// 防止恶意哈希串耗尽内存或 CPU
+const (
+	MaxArgon2Time    = 64
+	MaxArgon2Memory  = 1 << 20 // KiB，即 1 GiB
+	MaxArgon2Threads = 255
+	MaxScryptLogN    = 20
+	MaxScryptR       = 32
+	MaxScryptP       = 16
+	MaxScryptMemory  = 1 << 30 // 字节，128·r·N
+	MaxPBKDF2Iter    = 10000000
+)
+
+var ErrPasswordHash = errors.New("gcrypto: invalid password hash")
+
+// PasswordOptions 口令哈希选项，零值字段使用默认参数
+type PasswordOptions struct {
+	Alg PasswordAlg // 默认 argon2id
+
+	Time    uint32 // argon2id 迭代次数
+	Memory  uint32 // argon2id 内存（KiB）
+	Threads uint8  // argon2id 并行度
+
+	LogN int // scrypt N = 2^LogN
+	R, P int // scrypt r、p
+
+	Cost int // bcrypt cost
+
+	Iterations int // pbkdf2 迭代次数
+
+	SaltLen, KeyLen int
+}
+
+func (o PasswordOptions) withDefaults() PasswordOptions {
+	if o.Alg == "" {
+		o.Alg = PasswordArgon2id
+	}
+	setDefault := func(v *int, d int) {
+		if *v <= 0 {
+			*v = d
+		}
+	}
+	if o.Time == 0 {
+		o.Time = DefaultArgon2Time
+	}
+	if o.Memory == 0 {
+		o.Memory = DefaultArgon2Memory
+	}
+	if o.Threads == 0 {
+		o.Threads = DefaultArgon2Threads
+	}
+	setDefault(&o.LogN, DefaultScryptLogN)
+	setDefault(&o.R, DefaultScryptR)
+	setDefault(&o.P, DefaultScryptP)
+	setDefault(&o.Cost, DefaultBcryptCost)
+	setDefault(&o.Iterations, DefaultPBKDF2Iter)
+	setDefault(&o.SaltLen, DefaultSaltLen)
+	setDefault(&o.KeyLen, DefaultHashLen)
+	return o
+}
+
+// HashPassword 对口令加盐哈希，返回自描述的 PHC 格式字符串，如
+//
+//	$argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>
+//	$scrypt$ln=15,r=8,p=1$<salt>$<hash>
+//	$pbkdf2-sha256$i=600000$<salt>$<hash>
+//
+// bcrypt 使用其自身的 $2a$ 格式
+func HashPassword(password string, opt ...PasswordOptions) (string, error) {
+	option := PasswordOptions{}
+	if len(opt) > 0 {
+		option = opt[0]
+	}
+	option = option.withDefaults()
+	if option.Alg == PasswordBcrypt {
+		h, err := bcrypt.GenerateFromPassword([]byte(password), option.Cost)
+		return string(h), err
+	}
+	p := &phc{id: string(option.Alg), salt: make([]byte, option.SaltLen)}
+	if _, err := io.ReadFull(rand.Reader, p.salt); err != nil {
+		return "", err
+	}
+	switch option.Alg {
+	case PasswordArgon2id:
+		p.version = argon2.Version
+		p.params = []phcParam{{"m", int(option.Memory)}, {"t", int(option.Time)}, {"p", int(option.Threads)}}
+	case PasswordScrypt:
+		p.params = []phcParam{{"ln", option.LogN}, {"r", option.R}, {"p", option.P}}
+	case PasswordPBKDF2SHA256, PasswordPBKDF2SM3:
+		p.params = []phcParam{{"i", option.Iterations}}
+	default:
+		return "", fmt.Errorf("gcrypto: unsupported password algorithm %q", option.Alg)
+	}
+	var err error
+	if p.hash, err = p.derive(password, option.KeyLen); err != nil {
+		return "", err
+	}
+	return p.String(), nil
+}
+
+// VerifyPassword 校验口令与 HashPassword 的结果是否匹配，encoded 格式错误时返回 ErrPasswordHash
+func VerifyPassword(password, encoded string) (bool, error) {
+	if isBcrypt(encoded) {
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		switch {
+		case err == nil:
+			return true, nil
+		case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+			return false, nil
+		}
+		return false, fmt.Errorf("%w: %v", ErrPasswordHash, err)
+	}
+	p, err := parsePHC(encoded)
+	if err != nil {
+		return false, err
+	}
+	h, err := p.derive(password, len(p.hash))
+	if err != nil {
+		return false, err
+	}
+	return ConstantTimeEqual(h, p.hash), nil
+}
+
+// PasswordNeedsRehash encoded 的算法或参数与 opt 不一致时返回 true，
+// 应在 VerifyPassword 成功后用明文口令重新哈希并保存
+func PasswordNeedsRehash(encoded string, opt ...PasswordOptions) bool {
+	option := PasswordOptions{}
+	if len(opt) > 0 {
+		option = opt[0]
+	}
+	option = option.withDefaults()
+	if isBcrypt(encoded) {
+		cost, err := bcrypt.Cost([]byte(encoded))
+		return option.Alg != PasswordBcrypt || err != nil || cost != option.Cost
+	}
+	p, err := parsePHC(encoded)
+	if err != nil || p.id != string(option.Alg) || len(p.hash) != option.KeyLen || len(p.salt) < option.SaltLen {
+		return true
+	}
+	switch option.Alg {
+	case PasswordArgon2id:
+		return p.version != argon2.Version || p.param("m") != int(option.Memory) ||
+			p.param("t") != int(option.Time) || p.param("p") != int(option.Threads)
+	case PasswordScrypt:
+		return p.param("ln") != option.LogN || p.param("r") != option.R || p.param("p") != option.P
+	default:
+		return p.param("i") != option.Iterations
+	}
+}
+
+func isBcrypt(s string) bool {
+	return strings.HasPrefix(s, "$2a$") || strings.HasPrefix(s, "$2b$") || strings.HasPrefix(s, "$2y$")
+}
+
+// ---------------- PHC 字符串 ----------------
+
+type phcParam struct {
+	name  string
+	value int
+}
+
+// phc $<id>[$v=<version>]$<k>=<v>[,...]$<salt>$<hash>，salt 与 hash 为无填充 base64
+type phc struct {
+	id      string
+	version int
+	params  []phcParam
+	salt    []byte
+	hash    []byte
+}
+
+func (p *phc) param(name string) int {
+	for _, kv := range p.params {
+		if kv.name == name {
+			return kv.value
+		}
+	}
+	return -1
+}
+
+func (p *phc) String() string {
+	var b strings.Builder
+	b.WriteString("$" + p.id)
+	if p.version != 0 {
+		b.WriteString("$v=" + strconv.Itoa(p.version))
+	}
+	for i, kv := range p.params {
+		if i == 0 {
+			b.WriteByte('$')
+		} else {
+			b.WriteByte(',')
+		}
+		b.WriteString(kv.name + "=" + strconv.Itoa(kv.value))
+	}
+	b.WriteString("$" + base64.RawStdEncoding.EncodeToString(p.salt))
+	b.WriteString("$" + base64.RawStdEncoding.EncodeToString(p.hash))
+	return b.String()
+}
+
+func parsePHC(s string) (*phc, error) {
+	parts := strings.Split(s, "$")
+	if len(parts) < 5 || parts[0] != "" {
+		return nil, ErrPasswordHash
+	}
+	p := &phc{id: parts[1]}
+	rest := parts[2:]
+	if v, ok := strings.CutPrefix(rest[0], "v="); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, ErrPasswordHash
+		}
+		p.version, rest = n, rest[1:]
+	}
+	if len(rest) != 3 {
+		return nil, ErrPasswordHash
+	}
+	for _, kv := range strings.Split(rest[0], ",") {
+		name, value, ok := strings.Cut(kv, "=")
+		n, err := strconv.Atoi(value)
+		if !ok || err != nil || n <= 0 {
+			return nil, ErrPasswordHash
+		}
+		p.params = append(p.params, phcParam{name, n})
+	}
+	var err error
+	if p.salt, err = base64.RawStdEncoding.DecodeString(rest[1]); err != nil {
+		return nil, ErrPasswordHash
+	}
+	if p.hash, err = base64.RawStdEncoding.DecodeString(rest[2]); err != nil || len(p.hash) == 0 {
+		return nil, ErrPasswordHash
+	}
+	return p, nil
+}
+
+// limit 参数 name 缺失或不在 [1, max] 内时返回错误
+func (p *phc) limit(name string, max int) (int, error) {
+	v := p.param(name)
+	if v <= 0 || v > max {
+		return 0, fmt.Errorf("%w: %s=%d out of range", ErrPasswordHash, name, v)
+	}
+	return v, nil
+}
+
+// derive 按 p 的算法与参数计算 keyLen 字节哈希
+func (p *phc) derive(password string, keyLen int) ([]byte, error) {
+	switch PasswordAlg(p.id) {
+	case PasswordArgon2id:
+		if p.version != argon2.Version {
+			return nil, ErrPasswordHash
+		}
+		m, err := p.limit("m", MaxArgon2Memory)
+		if err != nil {
+			return nil, err
+		}
+		t, err := p.limit("t", MaxArgon2Time)
+		if err != nil {
+			return nil, err
+		}
+		threads, err := p.limit("p", MaxArgon2Threads)
+		if err != nil {
+			return nil, err
+		}
+		return argon2.IDKey([]byte(password), p.salt, uint32(t), uint32(m), uint8(threads), uint32(keyLen)), nil
+	case PasswordScrypt:
+		ln, err := p.limit("ln", MaxScryptLogN)
+		if err != nil {
+			return nil, err
+		}
+		r, err := p.limit("r", MaxScryptR)
+		if err != nil {
+			return nil, err
+		}
+		par, err := p.limit("p", MaxScryptP)
+		if err != nil {
+			return nil, err
+		}
+		if 128*r<<ln > MaxScryptMemory {
+			return nil, fmt.Errorf("%w: scrypt memory 128*%d*2^%d out of range", ErrPasswordHash, r, ln)
+		}
+		return Scrypt([]byte(password), p.salt, 1<<ln, r, par, keyLen)
+	case PasswordPBKDF2SHA256, PasswordPBKDF2SM3:
+		iter, err := p.limit("i", MaxPBKDF2Iter)
+		if err != nil {
+			return nil, err
+		}
+		alg := HashSHA256
+		if p.id == string(PasswordPBKDF2SM3) {
+			alg = HashSM3
+		}
+		return PBKDF2(alg, []byte(password), p.salt, iter, keyLen)
+	}
+	return nil, fmt.Errorf("%w: unsupported algorithm %q", ErrPasswordHash, p.id)
+}
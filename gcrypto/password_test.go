@@ -0,0 +1,154 @@
+package gcrypto
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 测试用的低成本参数
+var fastPassword = map[PasswordAlg]PasswordOptions{
+	PasswordArgon2id:     {Alg: PasswordArgon2id, Time: 1, Memory: 1024, Threads: 1},
+	PasswordScrypt:       {Alg: PasswordScrypt, LogN: 10},
+	PasswordBcrypt:       {Alg: PasswordBcrypt, Cost: 4},
+	PasswordPBKDF2SHA256: {Alg: PasswordPBKDF2SHA256, Iterations: 1000},
+	PasswordPBKDF2SM3:    {Alg: PasswordPBKDF2SM3, Iterations: 1000, KeyLen: 64},
+}
+
+func TestPasswordRoundTrip(t *testing.T) {
+	for alg, opt := range fastPassword {
+		encoded, err := HashPassword("correct horse", opt)
+		if err != nil {
+			t.Fatalf("%s: %v", alg, err)
+		}
+		if alg != PasswordBcrypt && !strings.HasPrefix(encoded, "$"+string(alg)+"$") {
+			t.Errorf("%s: unexpected encoding %s", alg, encoded)
+		}
+		if ok, err := VerifyPassword("correct horse", encoded); !ok || err != nil {
+			t.Errorf("%s: VerifyPassword = %v, %v", alg, ok, err)
+		}
+		if ok, err := VerifyPassword("correct horsE", encoded); ok || err != nil {
+			t.Errorf("%s: wrong password: %v, %v", alg, ok, err)
+		}
+		if again, _ := HashPassword("correct horse", opt); again == encoded {
+			t.Errorf("%s: salt not random", alg)
+		}
+	}
+}
+
+// 由其他实现生成的哈希串：argon2id 取自参考实现的测试，scrypt 与 pbkdf2 由 Python hashlib 生成
+func TestVerifyKnownPasswordHashes(t *testing.T) {
+	for _, encoded := range []string{
+		"$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc",
+		"$scrypt$ln=10,r=8,p=1$c29tZXNhbHRzb21lc2FsdA$dj05BT7oUTq35qmxXqG/pksYG8IJr8uxtvAzbfGjoic",
+		"$pbkdf2-sha256$i=1000$c29tZXNhbHRzb21lc2FsdA$s5LQUeAEZUMuFVrnmF3OMNPXs3QWnF8SO/5BXmCj6QQ",
+		// OpenBSD bcrypt 测试向量，$2b$ 仅在口令超过 255 字节时与 $2a$ 不同
+		"$2a$05$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvyOeW",
+		"$2b$05$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvyOeW",
+	} {
+		password := "password"
+		if strings.HasPrefix(encoded, "$2") {
+			password = "U*U"
+		}
+		if ok, err := VerifyPassword(password, encoded); !ok || err != nil {
+			t.Errorf("%s: VerifyPassword = %v, %v", encoded, ok, err)
+		}
+		if ok, err := VerifyPassword(password+"x", encoded); ok || err != nil {
+			t.Errorf("%s: wrong password: %v, %v", encoded, ok, err)
+		}
+	}
+}
+
+func TestVerifyPasswordRejectsExcessiveCost(t *testing.T) {
+	const salt, hash = "c29tZXNhbHQ", "CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"
+	for _, params := range []string{
+		"argon2id$v=19$m=4294967295,t=1,p=1",
+		"argon2id$v=19$m=65536,t=4294967295,p=1",
+		"argon2id$v=19$m=65536,t=1,p=256",
+		"argon2id$v=19$m=65536,t=1",
+		"argon2id$v=18$m=65536,t=1,p=1",
+		"scrypt$ln=40,r=8,p=1",
+		"scrypt$ln=15,r=1073741824,p=1",
+		"scrypt$ln=15,r=8,p=1073741824",
+		"scrypt$ln=20,r=32,p=1", // 4 GiB
+		"pbkdf2-sha256$i=2147483647",
+		"pbkdf2-sm3$i=2147483647",
+		"md5$i=1",
+	} {
+		start := time.Now()
+		ok, err := VerifyPassword("password", "$"+params+"$"+salt+"$"+hash)
+		if ok || !errors.Is(err, ErrPasswordHash) {
+			t.Errorf("%s: VerifyPassword = %v, %v", params, ok, err)
+		}
+		if d := time.Since(start); d > time.Second {
+			t.Errorf("%s: rejecting took %v", params, d)
+		}
+	}
+	if _, err := HashPassword("password", PasswordOptions{Memory: MaxArgon2Memory + 1}); !errors.Is(err, ErrPasswordHash) {
+		t.Errorf("HashPassword over the limit = %v", err)
+	}
+}
+
+func TestVerifyPasswordMalformed(t *testing.T) {
+	for _, encoded := range []string{
+		"",
+		"plain",
+		"$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ",
+		"$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$",
+		"$argon2id$v=19$m=65536,t=2,p=1$!!!$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc",
+		"$argon2id$v=x$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc",
+		"$argon2id$v=19$m=-1,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc",
+		"$2a$05$short",
+	} {
+		if ok, err := VerifyPassword("password", encoded); ok || !errors.Is(err, ErrPasswordHash) {
+			t.Errorf("%q: VerifyPassword = %v, %v", encoded, ok, err)
+		}
+	}
+}
+
+func TestPasswordNeedsRehash(t *testing.T) {
+	argon := fastPassword[PasswordArgon2id]
+	encoded, err := HashPassword("pw", argon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bcryptHash, err := HashPassword("pw", fastPassword[PasswordBcrypt])
+	if err != nil {
+		t.Fatal(err)
+	}
+	with := func(f func(o *PasswordOptions)) PasswordOptions {
+		o := argon
+		f(&o)
+		return o
+	}
+	for _, c := range []struct {
+		name    string
+		encoded string
+		opt     PasswordOptions
+		want    bool
+	}{
+		{"same", encoded, argon, false},
+		{"memory", encoded, with(func(o *PasswordOptions) { o.Memory = 2048 }), true},
+		{"time", encoded, with(func(o *PasswordOptions) { o.Time = 2 }), true},
+		{"threads", encoded, with(func(o *PasswordOptions) { o.Threads = 2 }), true},
+		{"key length", encoded, with(func(o *PasswordOptions) { o.KeyLen = 64 }), true},
+		{"salt length", encoded, with(func(o *PasswordOptions) { o.SaltLen = 32 }), true},
+		{"algorithm", encoded, fastPassword[PasswordScrypt], true},
+		{"defaults", encoded, PasswordOptions{}, true},
+		{"bcrypt same", bcryptHash, fastPassword[PasswordBcrypt], false},
+		{"bcrypt cost", bcryptHash, PasswordOptions{Alg: PasswordBcrypt, Cost: 5}, true},
+		{"bcrypt to argon2id", bcryptHash, argon, true},
+		{"scrypt params", "$scrypt$ln=10,r=8,p=1$c29tZXNhbHRzb21lc2FsdA$dj05BT7oUTq35qmxXqG/pksYG8IJr8uxtvAzbfGjoic",
+			PasswordOptions{Alg: PasswordScrypt, LogN: 10, SaltLen: 16}, false},
+		{"scrypt logN", "$scrypt$ln=10,r=8,p=1$c29tZXNhbHRzb21lc2FsdA$dj05BT7oUTq35qmxXqG/pksYG8IJr8uxtvAzbfGjoic",
+			PasswordOptions{Alg: PasswordScrypt, LogN: 11}, true},
+		{"pbkdf2 iterations", "$pbkdf2-sha256$i=1000$c29tZXNhbHRzb21lc2FsdA$s5LQUeAEZUMuFVrnmF3OMNPXs3QWnF8SO/5BXmCj6QQ",
+			PasswordOptions{Alg: PasswordPBKDF2SHA256}, true},
+		{"malformed", "garbage", argon, true},
+	} {
+		if got := PasswordNeedsRehash(c.encoded, c.opt); got != c.want {
+			t.Errorf("%s: PasswordNeedsRehash = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
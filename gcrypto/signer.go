@@ -0,0 +1,329 @@
+package gcrypto
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	stdx509 "crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/tjfoc/gmsm/sm2"
+	"github.com/tjfoc/gmsm/x509"
+)
+
+// SignAlg 签名算法，命名与 JWS 一致（SM2 除外）
+type SignAlg string
+
+const (
+	SigSM2   SignAlg = "SM2"   // SM2 + SM3，带用户 ID
+	SigES256 SignAlg = "ES256" // ECDSA P-256 + SHA-256，签名为 64 字节 r||s
+	SigES384 SignAlg = "ES384" // ECDSA P-384 + SHA-384，签名为 96 字节 r||s
+	SigEdDSA SignAlg = "EdDSA" // Ed25519
+	SigPS256 SignAlg = "PS256" // RSA-PSS + SHA-256
+	SigRS256 SignAlg = "RS256" // RSA PKCS#1 v1.5 + SHA-256
+)
+
+var ErrVerify = errors.New("gcrypto: signature verification failed")
+
+// Verifier 验签接口，与密钥算法无关
+type Verifier interface {
+	Algorithm() SignAlg
+	Public() crypto.PublicKey
+	// Verify 校验 msg 的签名，失败时返回 ErrVerify
+	Verify(msg, sig []byte) error
+}
+
+// Signer 签名接口，对原始消息签名（内部按算法计算摘要）
+type Signer interface {
+	Verifier
+	Sign(msg []byte) ([]byte, error)
+}
+
+// SignerOptions 签名选项
+type SignerOptions struct {
+	// Alg 指定算法，需与密钥类型匹配；为空时按密钥推断，RSA 默认 PS256
+	Alg SignAlg
+	// SM2 SM2 的用户 ID 与签名编码；ES256/ES384 按 JWS 固定为定长 r||s
+	SM2 SM2Options
+}
+
+// NewSigner 由私钥创建 Signer，支持 *sm2.PrivateKey、*ecdsa.PrivateKey、ed25519.PrivateKey 与 *rsa.PrivateKey
+func NewSigner(priv crypto.PrivateKey, opt ...SignerOptions) (Signer, error) {
+	var pub crypto.PublicKey
+	switch k := priv.(type) {
+	case *sm2.PrivateKey:
+		pub = &k.PublicKey
+	case *ecdsa.PrivateKey:
+		if k.Curve == sm2.P256Sm2() {
+			priv = &sm2.PrivateKey{PublicKey: sm2.PublicKey{Curve: k.Curve, X: k.X, Y: k.Y}, D: k.D}
+			pub = &priv.(*sm2.PrivateKey).PublicKey
+		} else {
+			pub = &k.PublicKey
+		}
+	case ed25519.PrivateKey:
+		pub = k.Public()
+	case *rsa.PrivateKey:
+		pub = &k.PublicKey
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedKeyType, priv)
+	}
+	v, err := NewVerifier(pub, opt...)
+	if err != nil {
+		return nil, err
+	}
+	return &signer{verifier: v.(*verifier), priv: priv}, nil
+}
+
+// NewVerifier 由公钥创建 Verifier，支持 *sm2.PublicKey、*ecdsa.PublicKey、ed25519.PublicKey 与 *rsa.PublicKey
+func NewVerifier(pub crypto.PublicKey, opt ...SignerOptions) (Verifier, error) {
+	option := SignerOptions{}
+	if len(opt) > 0 {
+		option = opt[0]
+	}
+	if k, ok := asSM2PublicKey(pub); ok {
+		pub = k
+	}
+	var alg SignAlg
+	switch k := pub.(type) {
+	case *sm2.PublicKey:
+		alg = SigSM2
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256():
+			alg = SigES256
+		case elliptic.P384():
+			alg = SigES384
+		default:
+			return nil, fmt.Errorf("%w: curve %s", ErrUnsupportedKeyType, k.Curve.Params().Name)
+		}
+	case ed25519.PublicKey:
+		alg = SigEdDSA
+	case *rsa.PublicKey:
+		alg = SigPS256
+		if option.Alg == SigRS256 {
+			alg = SigRS256
+		}
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedKeyType, pub)
+	}
+	if option.Alg != "" && option.Alg != alg {
+		return nil, fmt.Errorf("%w: %s cannot be used with %T", ErrUnsupportedKeyType, option.Alg, pub)
+	}
+	return &verifier{alg: alg, pub: pub, sm2: sm2Option([]SM2Options{option.SM2})}, nil
+}
+
+// ParseSignerPEM 解析 PEM 私钥并创建 Signer，密钥类型自动识别
+func ParseSignerPEM(data, password []byte, opt ...SignerOptions) (Signer, error) {
+	priv, err := ParsePrivateKeyPEM(data, password)
+	if err != nil {
+		return nil, err
+	}
+	return NewSigner(priv, opt...)
+}
+
+// ParseVerifierPEM 解析 PEM 公钥或证书并创建 Verifier，密钥类型自动识别
+func ParseVerifierPEM(data []byte, opt ...SignerOptions) (Verifier, error) {
+	pub, err := ParsePublicKeyPEM(data)
+	if err != nil {
+		return nil, err
+	}
+	return NewVerifier(pub, opt...)
+}
+
+type verifier struct {
+	alg SignAlg
+	pub crypto.PublicKey
+	sm2 SM2Options
+}
+
+func (v *verifier) Algorithm() SignAlg       { return v.alg }
+func (v *verifier) Public() crypto.PublicKey { return v.pub }
+
+func (v *verifier) Verify(msg, sig []byte) error {
+	ok := false
+	switch v.alg {
+	case SigSM2:
+		ok = sm2Verify(v.pub.(*sm2.PublicKey), msg, sig, v.sm2)
+	case SigES256:
+		h := sha256.Sum256(msg)
+		ok = ecdsaVerify(v.pub.(*ecdsa.PublicKey), h[:], sig)
+	case SigES384:
+		h := sha512.Sum384(msg)
+		ok = ecdsaVerify(v.pub.(*ecdsa.PublicKey), h[:], sig)
+	case SigEdDSA:
+		ok = ed25519.Verify(v.pub.(ed25519.PublicKey), msg, sig)
+	case SigPS256:
+		h := sha256.Sum256(msg)
+		ok = rsa.VerifyPSS(v.pub.(*rsa.PublicKey), crypto.SHA256, h[:], sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto}) == nil
+	case SigRS256:
+		h := sha256.Sum256(msg)
+		ok = rsa.VerifyPKCS1v15(v.pub.(*rsa.PublicKey), crypto.SHA256, h[:], sig) == nil
+	}
+	if !ok {
+		return ErrVerify
+	}
+	return nil
+}
+
+type signer struct {
+	*verifier
+	priv crypto.PrivateKey
+}
+
+func (s *signer) Sign(msg []byte) ([]byte, error) {
+	switch s.alg {
+	case SigSM2:
+		return sm2Sign(s.priv.(*sm2.PrivateKey), msg, s.sm2)
+	case SigES256:
+		h := sha256.Sum256(msg)
+		return ecdsaSign(s.priv.(*ecdsa.PrivateKey), h[:])
+	case SigES384:
+		h := sha512.Sum384(msg)
+		return ecdsaSign(s.priv.(*ecdsa.PrivateKey), h[:])
+	case SigEdDSA:
+		return ed25519.Sign(s.priv.(ed25519.PrivateKey), msg), nil
+	case SigPS256:
+		h := sha256.Sum256(msg)
+		return rsa.SignPSS(rand.Reader, s.priv.(*rsa.PrivateKey), crypto.SHA256, h[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	case SigRS256:
+		h := sha256.Sum256(msg)
+		return rsa.SignPKCS1v15(rand.Reader, s.priv.(*rsa.PrivateKey), crypto.SHA256, h[:])
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedKeyType, s.alg)
+}
+
+// ecdsaSign 按 JWS（RFC 7518 3.4）输出 r||s，各自按曲线长度定长编码
+func ecdsaSign(priv *ecdsa.PrivateKey, digest []byte) ([]byte, error) {
+	r, s, err := ecdsa.Sign(rand.Reader, priv, digest)
+	if err != nil {
+		return nil, err
+	}
+	size := (priv.Curve.Params().BitSize + 7) / 8
+	sig := make([]byte, 2*size)
+	r.FillBytes(sig[:size])
+	s.FillBytes(sig[size:])
+	return sig, nil
+}
+
+func ecdsaVerify(pub *ecdsa.PublicKey, digest, sig []byte) bool {
+	size := (pub.Curve.Params().BitSize + 7) / 8
+	if len(sig) != 2*size {
+		return false
+	}
+	return ecdsa.Verify(pub, digest, new(big.Int).SetBytes(sig[:size]), new(big.Int).SetBytes(sig[size:]))
+}
+
+// ---------------- PEM ----------------
+
+// ParsePrivateKeyPEM 解析 PEM 私钥并识别类型，支持 PRIVATE KEY（PKCS#8）、ENCRYPTED PRIVATE KEY、
+// RSA PRIVATE KEY（PKCS#1）与 EC PRIVATE KEY（SEC1），返回 *sm2.PrivateKey、*ecdsa.PrivateKey、
+// ed25519.PrivateKey 或 *rsa.PrivateKey
+func ParsePrivateKeyPEM(data, password []byte) (crypto.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrPEM
+	}
+	der := block.Bytes
+	switch block.Type {
+	case pemEncryptedPrivateKey:
+		if len(password) == 0 {
+			return nil, ErrPasswordNeed
+		}
+		plain, err := decryptPKCS8(der, password)
+		if err != nil {
+			return nil, err
+		}
+		key, err := parsePKCS8(plain)
+		if err != nil {
+			return nil, ErrPassword
+		}
+		return key, nil
+	case pemPrivateKey:
+		return parsePKCS8(der)
+	case "RSA PRIVATE KEY":
+		return stdx509.ParsePKCS1PrivateKey(der)
+	case pemECPrivateKey:
+		if key, err := stdx509.ParseECPrivateKey(der); err == nil {
+			return key, nil
+		}
+		return ParseSM2PrivateKey(der, nil)
+	}
+	return nil, fmt.Errorf("%w: unexpected type %q", ErrPEM, block.Type)
+}
+
+// ParsePublicKeyPEM 解析 PEM 公钥并识别类型，支持 PUBLIC KEY（SPKI）、RSA PUBLIC KEY（PKCS#1）与 CERTIFICATE
+func ParsePublicKeyPEM(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrPEM
+	}
+	switch block.Type {
+	case pemPublicKey:
+		if pub, err := stdx509.ParsePKIXPublicKey(block.Bytes); err == nil {
+			return pub, nil
+		}
+		return ParseSM2PublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return stdx509.ParsePKCS1PublicKey(block.Bytes)
+	case pemCertificate:
+		if cert, err := stdx509.ParseCertificate(block.Bytes); err == nil && cert.PublicKey != nil {
+			return cert.PublicKey, nil
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		if pub, ok := asSM2PublicKey(cert.PublicKey); ok {
+			return pub, nil
+		}
+		return cert.PublicKey, nil
+	}
+	return nil, fmt.Errorf("%w: unexpected type %q", ErrPEM, block.Type)
+}
+
+// MarshalPrivateKeyPEM 将私钥编码为 PKCS#8 PEM，password 非空时加密
+func MarshalPrivateKeyPEM(priv crypto.PrivateKey, password []byte) ([]byte, error) {
+	if k, ok := priv.(*sm2.PrivateKey); ok {
+		return EncodeSM2PrivateKeyPEM(k, password)
+	}
+	der, err := stdx509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	typ := pemPrivateKey
+	if len(password) > 0 {
+		if der, err = encryptPKCS8(der, password); err != nil {
+			return nil, err
+		}
+		typ = pemEncryptedPrivateKey
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), nil
+}
+
+// MarshalPublicKeyPEM 将公钥编码为 SPKI PEM
+func MarshalPublicKeyPEM(pub crypto.PublicKey) ([]byte, error) {
+	if k, ok := pub.(*sm2.PublicKey); ok {
+		return EncodeSM2PublicKeyPEM(k)
+	}
+	der, err := stdx509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: pemPublicKey, Bytes: der}), nil
+}
+
+// parsePKCS8 标准库不识别 SM2 曲线，失败时再按 SM2 解析
+func parsePKCS8(der []byte) (crypto.PrivateKey, error) {
+	if key, err := stdx509.ParsePKCS8PrivateKey(der); err == nil {
+		return key, nil
+	}
+	return ParseSM2PrivateKey(der, nil)
+}
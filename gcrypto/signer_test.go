@@ -0,0 +1,97 @@
+package gcrypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"math/big"
+	"testing"
+)
+
+// RFC 7515 附录 A.3 的 ES256 示例，签名为 r||s
+func TestES256JWSExample(t *testing.T) {
+	b64 := func(s string) []byte {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	pub := &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(b64("f83OJ3D2xF1Bg8vub9tLe1gHMzV76e8Tus9uPHvRVEU")),
+		Y:     new(big.Int).SetBytes(b64("x_FEzRu9m36HLN_tue659LNpXW6pCyStikYjKIWI5a0")),
+	}
+	v, err := NewVerifier(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Algorithm() != SigES256 {
+		t.Fatalf("Algorithm = %s", v.Algorithm())
+	}
+	input := []byte("eyJhbGciOiJFUzI1NiJ9.eyJpc3MiOiJqb2UiLA0KICJleHAiOjEzMDA4MTkzODAsDQogImh0dHA6Ly9leGFtcGxlLmNvbS9pc19yb290Ijp0cnVlfQ")
+	sig := b64("DtEhU3ljbEg8L38VWAfUAqOyKAM6-Xx-F4GawxaepmXFCgfTjDxw5djxLa8ISlSApmWQxfKTUJqPP3-Kg6NU1Q")
+	if err := v.Verify(input, sig); err != nil {
+		t.Fatalf("Verify = %v", err)
+	}
+	sig[0] ^= 1
+	if err := v.Verify(input, sig); err != ErrVerify {
+		t.Fatalf("tampered signature: %v", err)
+	}
+}
+
+func TestECDSASignerRawSignature(t *testing.T) {
+	for _, c := range []struct {
+		curve elliptic.Curve
+		alg   SignAlg
+		size  int
+		hash  func([]byte) []byte
+	}{
+		{elliptic.P256(), SigES256, 64, func(b []byte) []byte { h := sha256.Sum256(b); return h[:] }},
+		{elliptic.P384(), SigES384, 96, func(b []byte) []byte { h := sha512.Sum384(b); return h[:] }},
+	} {
+		priv, err := ecdsa.GenerateKey(c.curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := NewSigner(priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Algorithm() != c.alg {
+			t.Fatalf("Algorithm = %s, want %s", s.Algorithm(), c.alg)
+		}
+		msg := []byte("hello")
+		// 多签几次，覆盖 r 或 s 有前导零的情况
+		for i := 0; i < 32; i++ {
+			sig, err := s.Sign(msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(sig) != c.size {
+				t.Fatalf("%s signature is %d bytes, want %d", c.alg, len(sig), c.size)
+			}
+			if err := s.Verify(msg, sig); err != nil {
+				t.Fatalf("%s: %v", c.alg, err)
+			}
+		}
+		sig, _ := s.Sign(msg)
+		if err := s.Verify([]byte("hellO"), sig); err != ErrVerify {
+			t.Fatalf("%s: wrong message: %v", c.alg, err)
+		}
+		if err := s.Verify(msg, sig[:c.size-1]); err != ErrVerify {
+			t.Fatalf("%s: truncated signature: %v", c.alg, err)
+		}
+		// ASN.1 DER 签名不再被接受
+		der, err := ecdsa.SignASN1(rand.Reader, priv, c.hash(msg))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Verify(msg, der); err != ErrVerify {
+			t.Fatalf("%s: DER signature: %v", c.alg, err)
+		}
+	}
+}
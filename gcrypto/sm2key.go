@@ -0,0 +1,387 @@
+package gcrypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math/big"
+
+	"github.com/tjfoc/gmsm/sm2"
+	"github.com/tjfoc/gmsm/sm4"
+	"github.com/tjfoc/gmsm/x509"
+)
+
+const (
+	pemPrivateKey          = "PRIVATE KEY"
+	pemEncryptedPrivateKey = "ENCRYPTED PRIVATE KEY"
+	pemECPrivateKey        = "EC PRIVATE KEY"
+	pemPublicKey           = "PUBLIC KEY"
+
+	// PKCS8Iterations 加密 PKCS#8 私钥时 PBKDF2 的迭代次数
+	PKCS8Iterations = 100000
+	// MaxPKCS8Iterations 解密时接受的最大迭代次数，防止恶意密钥文件耗尽 CPU
+	MaxPKCS8Iterations = 10000000
+)
+
+var (
+	ErrPEM          = errors.New("gcrypto: invalid pem")
+	ErrPassword     = errors.New("gcrypto: incorrect password")
+	ErrPasswordNeed = errors.New("gcrypto: private key is encrypted, password required")
+)
+
+var (
+	oidPBES2        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA1 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACSHA256   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACSHA512   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+	oidAES128CBC    = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES256CBC    = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidSM4CBC       = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 104, 2}
+	oidECPublicKey  = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidSM2Curve     = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 301}
+)
+
+// GenerateSM2Key 生成 SM2 密钥对
+func GenerateSM2Key() (*sm2.PrivateKey, error) {
+	return sm2.GenerateKey(rand.Reader)
+}
+
+// FromSM2 导出私钥 D，固定 32 字节，可用 ToSM2 还原
+func FromSM2(priv *sm2.PrivateKey) []byte {
+	if priv == nil || priv.D == nil {
+		return nil
+	}
+	return priv.D.FillBytes(make([]byte, 32))
+}
+
+// FromSM2PubCompressed 导出压缩格式公钥 02/03||X（33 字节），UnmarshalPubkey 可解析
+func FromSM2PubCompressed(pub *sm2.PublicKey) []byte {
+	if pub == nil || pub.X == nil || pub.Y == nil {
+		return nil
+	}
+	out := make([]byte, 33)
+	out[0] = 2 + byte(pub.Y.Bit(0))
+	pub.X.FillBytes(out[1:])
+	return out
+}
+
+// decompressSM2Pub 由 02/03||X 求 Y：y² = x³ - 3x + b (mod p)
+func decompressSM2Pub(b []byte) (*sm2.PublicKey, error) {
+	if len(b) != 33 || (b[0] != 2 && b[0] != 3) {
+		return nil, errInvalidPubkey
+	}
+	params := sm2.P256Sm2().Params()
+	p := params.P
+	x := new(big.Int).SetBytes(b[1:])
+	if x.Cmp(p) >= 0 {
+		return nil, errInvalidPubkey
+	}
+	y2 := new(big.Int).Exp(x, big.NewInt(3), p)
+	y2.Sub(y2, new(big.Int).Lsh(x, 1))
+	y2.Sub(y2, x)
+	y2.Add(y2, params.B)
+	y2.Mod(y2, p)
+	y := new(big.Int).ModSqrt(y2, p)
+	if y == nil {
+		return nil, errInvalidPubkey
+	}
+	if y.Bit(0) != uint(b[0]&1) {
+		y.Sub(p, y)
+	}
+	return &sm2.PublicKey{Curve: sm2.P256Sm2(), X: x, Y: y}, nil
+}
+
+// ---------------- PKCS#8 / SPKI ----------------
+
+// MarshalSM2PrivateKey 编码为 PKCS#8 DER；password 非空时使用 PBES2（PBKDF2-HMAC-SHA256 + AES-256-CBC）加密
+func MarshalSM2PrivateKey(priv *sm2.PrivateKey, password []byte) ([]byte, error) {
+	der, err := x509.MarshalSm2UnecryptedPrivateKey(priv)
+	if err != nil || len(password) == 0 {
+		return der, err
+	}
+	return encryptPKCS8(der, password)
+}
+
+// ParseSM2PrivateKey 解析 PKCS#8 DER（password 非空时按加密格式解析）或 SEC1 DER
+func ParseSM2PrivateKey(der, password []byte) (*sm2.PrivateKey, error) {
+	if len(password) > 0 {
+		plain, err := decryptPKCS8(der, password)
+		if err != nil {
+			return nil, err
+		}
+		der = plain
+	}
+	// 结构正确但曲线或公钥不符时直接返回，不再当作口令错误
+	for _, parse := range []func([]byte) (*sm2.PrivateKey, error){parseSM2PKCS8, parseSM2ECKey} {
+		priv, err := parse(der)
+		if err == nil || errors.Is(err, ErrInvalidKey) {
+			return priv, err
+		}
+	}
+	if len(password) > 0 {
+		return nil, ErrPassword
+	}
+	if isEncryptedPKCS8(der) {
+		return nil, ErrPasswordNeed
+	}
+	return nil, fmt.Errorf("%w: not an sm2 private key", ErrInvalidKey)
+}
+
+// MarshalSM2PublicKey 编码为 SubjectPublicKeyInfo DER
+func MarshalSM2PublicKey(pub *sm2.PublicKey) ([]byte, error) {
+	return x509.MarshalSm2PublicKey(pub)
+}
+
+// ParseSM2PublicKey 解析 SubjectPublicKeyInfo DER，曲线须为 SM2
+func ParseSM2PublicKey(der []byte) (*sm2.PublicKey, error) {
+	var spki struct {
+		Algo      pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if rest, err := asn1.Unmarshal(der, &spki); err != nil || len(rest) > 0 {
+		return nil, fmt.Errorf("%w: malformed public key", ErrInvalidKey)
+	}
+	if !isSM2Algorithm(spki.Algo) {
+		return nil, fmt.Errorf("%w: not an sm2 public key", ErrInvalidKey)
+	}
+	pub, err := UnmarshalPubkey(spki.PublicKey.RightAlign())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	return pub, nil
+}
+
+// tjfoc 解析 PKCS#8 与 SPKI 时只检查 id-ecPublicKey 而忽略曲线参数，P-256 等其他曲线的密钥会被当作 SM2，
+// 因此这里自行解析并校验曲线
+
+type sm2ECPrivateKey struct {
+	Version       int
+	PrivateKey    []byte
+	NamedCurveOID asn1.ObjectIdentifier `asn1:"optional,explicit,tag:0"`
+	PublicKey     asn1.BitString        `asn1:"optional,explicit,tag:1"`
+}
+
+// isSM2Algorithm 算法须为 id-ecPublicKey 且参数为 SM2 曲线 OID
+func isSM2Algorithm(algo pkix.AlgorithmIdentifier) bool {
+	var curve asn1.ObjectIdentifier
+	if !algo.Algorithm.Equal(oidECPublicKey) {
+		return false
+	}
+	rest, err := asn1.Unmarshal(algo.Parameters.FullBytes, &curve)
+	return err == nil && len(rest) == 0 && curve.Equal(oidSM2Curve)
+}
+
+// parseSM2PKCS8 解析未加密的 PKCS#8；结构正确但不是 SM2 密钥时返回 ErrInvalidKey
+func parseSM2PKCS8(der []byte) (*sm2.PrivateKey, error) {
+	var p struct {
+		Version    int
+		Algo       pkix.AlgorithmIdentifier
+		PrivateKey []byte
+	}
+	if rest, err := asn1.Unmarshal(der, &p); err != nil || len(rest) > 0 {
+		return nil, errors.New("not pkcs8")
+	}
+	if !isSM2Algorithm(p.Algo) {
+		return nil, fmt.Errorf("%w: not an sm2 private key", ErrInvalidKey)
+	}
+	return parseSM2EC(p.PrivateKey, false)
+}
+
+// parseSM2ECKey 解析 SEC1 私钥，须带 SM2 曲线 OID
+func parseSM2ECKey(der []byte) (*sm2.PrivateKey, error) {
+	return parseSM2EC(der, true)
+}
+
+// parseSM2EC 解析 SEC1 私钥；PKCS#8 内层可省略曲线 OID，出现时须为 SM2；带公钥时须与私钥一致
+func parseSM2EC(der []byte, named bool) (*sm2.PrivateKey, error) {
+	var k sm2ECPrivateKey
+	if rest, err := asn1.Unmarshal(der, &k); err != nil || len(rest) > 0 {
+		return nil, errors.New("not sec1")
+	}
+	if (named || len(k.NamedCurveOID) > 0) && !k.NamedCurveOID.Equal(oidSM2Curve) {
+		return nil, fmt.Errorf("%w: not an sm2 private key", ErrInvalidKey)
+	}
+	d := new(big.Int).SetBytes(k.PrivateKey)
+	if d.BitLen() > 256 {
+		return nil, fmt.Errorf("%w: invalid private key length", ErrInvalidKey)
+	}
+	priv, err := ToSM2(d.FillBytes(make([]byte, 32)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	if len(k.PublicKey.Bytes) > 0 {
+		pub, err := UnmarshalPubkey(k.PublicKey.RightAlign())
+		if err != nil || pub.X.Cmp(priv.X) != 0 || pub.Y.Cmp(priv.Y) != 0 {
+			return nil, fmt.Errorf("%w: public key does not match private key", ErrInvalidKey)
+		}
+	}
+	return priv, nil
+}
+
+// EncodeSM2PrivateKeyPEM 编码为 PEM，password 非空时为 ENCRYPTED PRIVATE KEY
+func EncodeSM2PrivateKeyPEM(priv *sm2.PrivateKey, password []byte) ([]byte, error) {
+	der, err := MarshalSM2PrivateKey(priv, password)
+	if err != nil {
+		return nil, err
+	}
+	typ := pemPrivateKey
+	if len(password) > 0 {
+		typ = pemEncryptedPrivateKey
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), nil
+}
+
+// DecodeSM2PrivateKeyPEM 解析 PRIVATE KEY、ENCRYPTED PRIVATE KEY 或 EC PRIVATE KEY 格式的 PEM
+func DecodeSM2PrivateKeyPEM(data, password []byte) (*sm2.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrPEM
+	}
+	switch block.Type {
+	case pemEncryptedPrivateKey:
+		if len(password) == 0 {
+			return nil, ErrPasswordNeed
+		}
+		return ParseSM2PrivateKey(block.Bytes, password)
+	case pemPrivateKey, pemECPrivateKey:
+		return ParseSM2PrivateKey(block.Bytes, nil)
+	}
+	return nil, fmt.Errorf("%w: unexpected type %q", ErrPEM, block.Type)
+}
+
+// EncodeSM2PublicKeyPEM 编码为 PUBLIC KEY 格式的 PEM
+func EncodeSM2PublicKeyPEM(pub *sm2.PublicKey) ([]byte, error) {
+	der, err := MarshalSM2PublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: pemPublicKey, Bytes: der}), nil
+}
+
+// DecodeSM2PublicKeyPEM 解析 PUBLIC KEY 格式的 PEM
+func DecodeSM2PublicKeyPEM(data []byte) (*sm2.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != pemPublicKey {
+		return nil, ErrPEM
+	}
+	return ParseSM2PublicKey(block.Bytes)
+}
+
+// ---------------- PBES2 (RFC 8018) ----------------
+
+func encryptPKCS8(der, password []byte) ([]byte, error) {
+	salt := make([]byte, 16)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return nil, err
+	}
+	key, err := pbkdf2.Key(sha256.New, string(password), salt, PKCS8Iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	data := pkcs7Pad(der, aes.BlockSize)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(data, data)
+	info := x509.EncryptedPrivateKeyInfo{
+		EncryptionAlgorithm: x509.Pbes2Algorithms{
+			IdPBES2: oidPBES2,
+			Pbes2Params: x509.Pbes2Params{
+				KeyDerivationFunc: x509.Pbes2KDfs{
+					IdPBKDF2: oidPBKDF2,
+					Pkdf2Params: x509.Pkdf2Params{
+						Salt:           salt,
+						IterationCount: PKCS8Iterations,
+						Prf:            pkix.AlgorithmIdentifier{Algorithm: oidHMACSHA256, Parameters: asn1.NullRawValue},
+					},
+				},
+				EncryptionScheme: x509.Pbes2Encs{EncryAlgo: oidAES256CBC, IV: iv},
+			},
+		},
+		EncryptedData: data,
+	}
+	return asn1.Marshal(info)
+}
+
+func isEncryptedPKCS8(der []byte) bool {
+	var info x509.EncryptedPrivateKeyInfo
+	_, err := asn1.Unmarshal(der, &info)
+	return err == nil && info.EncryptionAlgorithm.IdPBES2.Equal(oidPBES2)
+}
+
+// decryptPKCS8 支持 PBKDF2（HMAC-SHA1/SHA256/SHA512）与 AES-128/256-CBC、SM4-CBC
+func decryptPKCS8(der, password []byte) ([]byte, error) {
+	var info x509.EncryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, fmt.Errorf("%w: not an encrypted pkcs8 key", ErrInvalidKey)
+	}
+	alg := info.EncryptionAlgorithm
+	kdf := alg.Pbes2Params.KeyDerivationFunc
+	if !alg.IdPBES2.Equal(oidPBES2) || !kdf.IdPBKDF2.Equal(oidPBKDF2) {
+		return nil, fmt.Errorf("%w: only PBES2 with PBKDF2 is supported", ErrInvalidKey)
+	}
+	var h func() hash.Hash
+	switch prf := kdf.Pkdf2Params.Prf.Algorithm; {
+	case len(prf) == 0, prf.Equal(oidHMACWithSHA1):
+		h = sha1.New
+	case prf.Equal(oidHMACSHA256):
+		h = sha256.New
+	case prf.Equal(oidHMACSHA512):
+		h = sha512.New
+	default:
+		return nil, fmt.Errorf("%w: unsupported prf %v", ErrInvalidKey, prf)
+	}
+	enc := alg.Pbes2Params.EncryptionScheme
+	var newCipher func([]byte) (cipher.Block, error)
+	keyLen := 16
+	switch {
+	case enc.EncryAlgo.Equal(oidAES128CBC):
+		newCipher = aes.NewCipher
+	case enc.EncryAlgo.Equal(oidAES256CBC):
+		newCipher, keyLen = aes.NewCipher, 32
+	case enc.EncryAlgo.Equal(oidSM4CBC):
+		newCipher = sm4.NewCipher
+	default:
+		return nil, fmt.Errorf("%w: unsupported cipher %v", ErrInvalidKey, enc.EncryAlgo)
+	}
+	iter := kdf.Pkdf2Params.IterationCount
+	if iter <= 0 || iter > MaxPKCS8Iterations {
+		return nil, fmt.Errorf("%w: invalid iteration count %d", ErrInvalidKey, iter)
+	}
+	key, err := pbkdf2.Key(h, string(password), kdf.Pkdf2Params.Salt, iter, keyLen)
+	if err != nil {
+		return nil, err
+	}
+	block, err := newCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	data := info.EncryptedData
+	if len(enc.IV) != block.BlockSize() || len(data) == 0 || len(data)%block.BlockSize() != 0 {
+		return nil, fmt.Errorf("%w: malformed encrypted pkcs8 key", ErrInvalidKey)
+	}
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, enc.IV).CryptBlocks(plain, data)
+	plain, err = pkcs7Unpad(plain, block.BlockSize())
+	if err != nil {
+		return nil, ErrPassword
+	}
+	return plain, nil
+}
@@ -0,0 +1,187 @@
+package gcrypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	stdx509 "crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tjfoc/gmsm/x509"
+)
+
+func TestEncryptedPKCS8RoundTrip(t *testing.T) {
+	priv, err := GenerateSM2Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := MarshalSM2PrivateKey(priv, []byte("pw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ParseSM2PrivateKey(der, []byte("pw"))
+	if err != nil || got.D.Cmp(priv.D) != 0 {
+		t.Fatalf("ParseSM2PrivateKey = %v", err)
+	}
+	if _, err := ParseSM2PrivateKey(der, []byte("wrong")); !errors.Is(err, ErrPassword) {
+		t.Fatalf("wrong password: %v", err)
+	}
+}
+
+func TestEncryptedPKCS8RejectsExcessiveIterations(t *testing.T) {
+	priv, err := GenerateSM2Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := MarshalSM2PrivateKey(priv, []byte("pw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var info x509.EncryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		t.Fatal(err)
+	}
+	info.EncryptionAlgorithm.Pbes2Params.KeyDerivationFunc.Pkdf2Params.IterationCount = 1<<31 - 1
+	evil, err := asn1.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if _, err := ParseSM2PrivateKey(evil, []byte("pw")); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("ParseSM2PrivateKey = %v, want ErrInvalidKey", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("rejecting the key took %v", d)
+	}
+}
+
+// ecPKCS8 以给定曲线 OID 构造 PKCS#8，内层 SEC1 不带曲线 OID
+func ecPKCS8(t *testing.T, curve asn1.ObjectIdentifier, d []byte) []byte {
+	t.Helper()
+	inner, err := asn1.Marshal(sm2ECPrivateKey{Version: 1, PrivateKey: d})
+	if err != nil {
+		t.Fatal(err)
+	}
+	params, _ := asn1.Marshal(curve)
+	der, err := asn1.Marshal(struct {
+		Version    int
+		Algo       pkix.AlgorithmIdentifier
+		PrivateKey []byte
+	}{0, pkix.AlgorithmIdentifier{Algorithm: oidECPublicKey, Parameters: asn1.RawValue{FullBytes: params}}, inner})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func TestParseSM2RejectsOtherCurves(t *testing.T) {
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8, _ := stdx509.MarshalPKCS8PrivateKey(p256)
+	sec1, _ := stdx509.MarshalECPrivateKey(p256)
+	spki, _ := stdx509.MarshalPKIXPublicKey(&p256.PublicKey)
+	secp256k1 := ecPKCS8(t, asn1.ObjectIdentifier{1, 3, 132, 0, 10}, p256.D.Bytes())
+
+	for name, der := range map[string][]byte{"p256 pkcs8": pkcs8, "p256 sec1": sec1, "secp256k1 pkcs8": secp256k1} {
+		if _, err := ParseSM2PrivateKey(der, nil); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("%s: ParseSM2PrivateKey = %v, want ErrInvalidKey", name, err)
+		}
+	}
+	if _, err := ParseSM2PublicKey(spki); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("p256 spki: ParseSM2PublicKey = %v, want ErrInvalidKey", err)
+	}
+
+	// 口令正确但密钥不是 SM2 时报告 ErrInvalidKey 而不是口令错误
+	enc, err := MarshalPrivateKeyPEM(p256, []byte("pw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodeSM2PrivateKeyPEM(enc, []byte("pw")); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("encrypted p256: DecodeSM2PrivateKeyPEM = %v, want ErrInvalidKey", err)
+	}
+
+	// 通用解析不能把其他曲线的密钥回落为 SM2
+	block := pem.EncodeToMemory(&pem.Block{Type: pemPrivateKey, Bytes: secp256k1})
+	if s, err := ParseSignerPEM(block, nil); err == nil {
+		t.Errorf("secp256k1 key parsed as %s signer", s.Algorithm())
+	}
+	block = pem.EncodeToMemory(&pem.Block{Type: pemECPrivateKey, Bytes: secp256k1SEC1(t, p256.D.Bytes())})
+	if s, err := ParseSignerPEM(block, nil); err == nil {
+		t.Errorf("secp256k1 sec1 key parsed as %s signer", s.Algorithm())
+	}
+}
+
+func secp256k1SEC1(t *testing.T, d []byte) []byte {
+	t.Helper()
+	der, err := asn1.Marshal(sm2ECPrivateKey{Version: 1, PrivateKey: d, NamedCurveOID: asn1.ObjectIdentifier{1, 3, 132, 0, 10}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func TestParseSM2ValidatesKeyMaterial(t *testing.T) {
+	priv, err := GenerateSM2Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := GenerateSM2Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := FromSM2(priv)
+	sec1 := func(k sm2ECPrivateKey) []byte {
+		der, err := asn1.Marshal(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return der
+	}
+
+	// 合法的 SEC1 与省略内层曲线 OID 的 PKCS#8
+	for name, der := range map[string][]byte{
+		"sec1":  sec1(sm2ECPrivateKey{Version: 1, PrivateKey: d, NamedCurveOID: oidSM2Curve, PublicKey: asn1.BitString{Bytes: FromSM2Pub(&priv.PublicKey), BitLength: 520}}),
+		"pkcs8": ecPKCS8(t, oidSM2Curve, d),
+	} {
+		got, err := ParseSM2PrivateKey(der, nil)
+		if err != nil || got.D.Cmp(priv.D) != 0 || got.X.Cmp(priv.X) != 0 {
+			t.Errorf("%s: ParseSM2PrivateKey = %v", name, err)
+		}
+	}
+
+	for name, der := range map[string][]byte{
+		"sec1 without curve": sec1(sm2ECPrivateKey{Version: 1, PrivateKey: d}),
+		"mismatched public key": sec1(sm2ECPrivateKey{Version: 1, PrivateKey: d, NamedCurveOID: oidSM2Curve,
+			PublicKey: asn1.BitString{Bytes: FromSM2Pub(&other.PublicKey), BitLength: 520}}),
+		"zero scalar":     ecPKCS8(t, oidSM2Curve, make([]byte, 32)),
+		"scalar too long": ecPKCS8(t, oidSM2Curve, append([]byte{1}, d...)),
+	} {
+		if _, err := ParseSM2PrivateKey(der, nil); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("%s: ParseSM2PrivateKey = %v, want ErrInvalidKey", name, err)
+		}
+	}
+
+	spki, err := MarshalSM2PublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub, err := ParseSM2PublicKey(spki); err != nil || pub.X.Cmp(priv.X) != 0 {
+		t.Fatalf("ParseSM2PublicKey = %v", err)
+	}
+	// 点不在曲线上
+	bad := append([]byte(nil), spki...)
+	bad[len(bad)-1] ^= 1
+	if _, err := ParseSM2PublicKey(bad); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("off-curve point: ParseSM2PublicKey = %v, want ErrInvalidKey", err)
+	}
+	if _, err := ParseSM2PublicKey(append(spki, 0)); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("trailing data: ParseSM2PublicKey = %v, want ErrInvalidKey", err)
+	}
+}
@@ -0,0 +1,201 @@
+package gcrypto
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/tjfoc/gmsm/sm2"
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+var (
+	ErrKeyExchange        = errors.New("gcrypto: sm2 key exchange failed")
+	ErrKeyExchangeConfirm = errors.New("gcrypto: sm2 key exchange confirmation mismatch")
+)
+
+// DefaultSM2KeyLen 协商密钥的默认长度（字节）
+const DefaultSM2KeyLen = 16
+
+// SM2KeyExchangeOptions SM2 密钥交换选项
+type SM2KeyExchangeOptions struct {
+	UID     []byte // 本方用户 ID，默认 SM2DefaultUID
+	PeerUID []byte // 对方用户 ID，默认 SM2DefaultUID
+	KeyLen  int    // 协商密钥长度（字节），默认 DefaultSM2KeyLen
+	// Ephemeral 本方临时密钥，默认随机生成；仅用于按标准测试向量复现
+	Ephemeral *sm2.PrivateKey
+}
+
+// SM2KeyExchange SM2 密钥交换协议（GB/T 32918.3）一方的状态，发起方为 A，响应方为 B：
+//
+//	A → B: A.EphemeralPublic()
+//	B: B.Compute(RA) 得到密钥；B → A: B.EphemeralPublic(), B.Confirmation()
+//	A: A.Compute(RB) 得到密钥，A.Verify(SB)；A → B: A.Confirmation()
+//	B: B.Verify(SA)
+//
+// 确认步骤可选，但省略时无法确认对方持有对应私钥
+type SM2KeyExchange struct {
+	initiator bool
+	curve     elliptic.Curve
+	priv      *sm2.PrivateKey
+	peer      *sm2.PublicKey
+	eph       *sm2.PrivateKey
+	za, zb    []byte
+	klen      int
+
+	confirm []byte // 发给对方的确认值
+	expect  []byte // 期望收到的确认值
+}
+
+// NewSM2KeyExchange 创建密钥交换，initiator 为 true 时本方为发起方 A
+func NewSM2KeyExchange(priv *sm2.PrivateKey, peer *sm2.PublicKey, initiator bool, opt ...SM2KeyExchangeOptions) (*SM2KeyExchange, error) {
+	option := SM2KeyExchangeOptions{}
+	if len(opt) > 0 {
+		option = opt[0]
+	}
+	if len(option.UID) == 0 {
+		option.UID = SM2DefaultUID
+	}
+	if len(option.PeerUID) == 0 {
+		option.PeerUID = SM2DefaultUID
+	}
+	if option.KeyLen <= 0 {
+		option.KeyLen = DefaultSM2KeyLen
+	}
+	if priv == nil || peer == nil || !sm2.P256Sm2().IsOnCurve(peer.X, peer.Y) {
+		return nil, ErrInvalidKey
+	}
+	eph := option.Ephemeral
+	if eph == nil {
+		var err error
+		if eph, err = sm2.GenerateKey(rand.Reader); err != nil {
+			return nil, err
+		}
+	}
+	own, err := sm2.ZA(&priv.PublicKey, option.UID)
+	if err != nil {
+		return nil, err
+	}
+	other, err := sm2.ZA(peer, option.PeerUID)
+	if err != nil {
+		return nil, err
+	}
+	kx := &SM2KeyExchange{initiator: initiator, curve: sm2.P256Sm2(), priv: priv, peer: peer, eph: eph, klen: option.KeyLen}
+	kx.za, kx.zb = own, other
+	if !initiator {
+		kx.za, kx.zb = other, own
+	}
+	return kx, nil
+}
+
+// EphemeralPublic 本方临时公钥 04||X||Y，发给对方
+func (kx *SM2KeyExchange) EphemeralPublic() []byte {
+	return elliptic.Marshal(kx.curve, kx.eph.PublicKey.X, kx.eph.PublicKey.Y)
+}
+
+// Compute 由对方临时公钥计算共享密钥，之后可调用 Confirmation 与 Verify
+func (kx *SM2KeyExchange) Compute(peerEphemeral []byte) ([]byte, error) {
+	curve := kx.curve
+	n := curve.Params().N
+	rx, ry := elliptic.Unmarshal(curve, peerEphemeral)
+	if rx == nil {
+		return nil, ErrKeyExchange
+	}
+	// t = (d + x̄·r) mod n
+	t := new(big.Int).Mul(sm2XHat(kx.eph.PublicKey.X), kx.eph.D)
+	t.Add(t, kx.priv.D)
+	t.Mod(t, n)
+	// U/V = [t](P + [x̄']R')，SM2 曲线余因子为 1
+	x, y := curve.ScalarMult(rx, ry, sm2XHat(rx).Bytes())
+	x, y = curve.Add(kx.peer.X, kx.peer.Y, x, y)
+	x, y = curve.ScalarMult(x, y, t.Bytes())
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil, ErrKeyExchange
+	}
+	xv, yv := sm2Coord(x), sm2Coord(y)
+
+	key := sm2KDF(kx.klen, xv, yv, kx.za, kx.zb)
+	if key == nil {
+		return nil, ErrKeyExchange
+	}
+	// Hash(xV || ZA || ZB || x1 || y1 || x2 || y2)，(x1,y1) 为 RA，(x2,y2) 为 RB
+	ra, rb := kx.EphemeralPublic()[1:], peerEphemeral[1:]
+	if !kx.initiator {
+		ra, rb = rb, ra
+	}
+	inner := sm3.Sm3Sum(concat(xv, kx.za, kx.zb, ra, rb))
+	s2 := sm3.Sm3Sum(concat([]byte{0x02}, yv, inner)) // SB / S1
+	s3 := sm3.Sm3Sum(concat([]byte{0x03}, yv, inner)) // SA / S2
+	if kx.initiator {
+		kx.confirm, kx.expect = s3, s2
+	} else {
+		kx.confirm, kx.expect = s2, s3
+	}
+	return key, nil
+}
+
+// Confirmation 发给对方的确认值：响应方为 SB，发起方为 SA；须在 Compute 之后调用
+func (kx *SM2KeyExchange) Confirmation() []byte {
+	return kx.confirm
+}
+
+// Verify 校验对方的确认值：发起方校验 SB，响应方校验 SA
+func (kx *SM2KeyExchange) Verify(peerConfirmation []byte) error {
+	if kx.expect == nil {
+		return ErrKeyExchange
+	}
+	if subtle.ConstantTimeCompare(kx.expect, peerConfirmation) != 1 {
+		return ErrKeyExchangeConfirm
+	}
+	return nil
+}
+
+// sm2XHat x̄ = 2^w + (x & (2^w - 1))，w = 127
+func sm2XHat(x *big.Int) *big.Int {
+	w := new(big.Int).Lsh(big.NewInt(1), 127)
+	low := new(big.Int).Sub(w, big.NewInt(1))
+	low.And(low, x)
+	return low.Add(low, w)
+}
+
+// sm2Coord 坐标定长 32 字节编码
+func sm2Coord(v *big.Int) []byte {
+	return v.FillBytes(make([]byte, 32))
+}
+
+// sm2KDF GB/T 32918 密钥派生函数：SM3(Z || ct)，ct 为从 1 开始的 32 位计数器；结果全零时返回 nil
+func sm2KDF(klen int, z ...[]byte) []byte {
+	out := make([]byte, 0, klen+32)
+	var ct [4]byte
+	for i := uint32(1); len(out) < klen; i++ {
+		binary.BigEndian.PutUint32(ct[:], i)
+		h := sm3.New()
+		for _, b := range z {
+			h.Write(b)
+		}
+		h.Write(ct[:])
+		out = append(out, h.Sum(nil)...) // tjfoc 的 Sum 会把参数写入摘要而非追加，只能传 nil
+	}
+	out = out[:klen]
+	for _, b := range out {
+		if b != 0 {
+			return out
+		}
+	}
+	return nil
+}
+
+func concat(parts ...[]byte) []byte {
+	var n int
+	for _, p := range parts {
+		n += len(p)
+	}
+	out := make([]byte, 0, n)
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
@@ -0,0 +1,265 @@
+package gcrypto
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+func bigHex(s string) *big.Int {
+	v, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		panic("invalid hex " + s)
+	}
+	return v
+}
+
+func unhex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// sampleCurve GB/T 32918.3 附录 A 的示例曲线 y² = x³ + ax + b，a ≠ -3，仿射坐标实现，仅供测试
+type sampleCurve struct {
+	*elliptic.CurveParams
+	a *big.Int
+}
+
+var gbSampleCurve = &sampleCurve{
+	CurveParams: &elliptic.CurveParams{
+		Name:    "GB/T 32918 sample",
+		BitSize: 256,
+		P:       bigHex("8542D69E4C044F18E8B92435BF6FF7DE457283915C45517D722EDB8B08F1DFC3"),
+		N:       bigHex("8542D69E4C044F18E8B92435BF6FF7DD297720630485628D5AE74EE7C32E79B7"),
+		B:       bigHex("63E4C6D3B23B0C849CF84241484BFE48F61D59A5B16BA06E6E12D1DA27C5249A"),
+		Gx:      bigHex("421DEBD61B62EAB6746434EBC3CC315E32220B3BADD50BDC4C4E6C147FEDD43D"),
+		Gy:      bigHex("0680512BCBB42C07D47349D2153B70C4E5D7FDFCBFA36EA1A85841B9E46E09A2"),
+	},
+	a: bigHex("787968B4FA32C3FD2417842E73BBFEFF2F3C848B6831D7E0EC65228B3937E498"),
+}
+
+func (c *sampleCurve) Params() *elliptic.CurveParams { return c.CurveParams }
+
+func (c *sampleCurve) IsOnCurve(x, y *big.Int) bool {
+	lhs := new(big.Int).Mul(y, y)
+	rhs := new(big.Int).Mul(x, x)
+	rhs.Add(rhs, c.a).Mul(rhs, x).Add(rhs, c.B)
+	return lhs.Sub(lhs, rhs).Mod(lhs, c.P).Sign() == 0
+}
+
+// line 由斜率 num/den 求和点，无穷远点记为 (0, 0)
+func (c *sampleCurve) line(x1, y1, x2 *big.Int, num, den *big.Int) (*big.Int, *big.Int) {
+	l := new(big.Int).ModInverse(den.Mod(den, c.P), c.P)
+	l.Mul(l, num).Mod(l, c.P)
+	x3 := new(big.Int).Mul(l, l)
+	x3.Sub(x3, x1).Sub(x3, x2).Mod(x3, c.P)
+	y3 := new(big.Int).Sub(x1, x3)
+	y3.Mul(y3, l).Sub(y3, y1).Mod(y3, c.P)
+	return x3, y3
+}
+
+func (c *sampleCurve) Double(x, y *big.Int) (*big.Int, *big.Int) {
+	if y.Sign() == 0 {
+		return new(big.Int), new(big.Int)
+	}
+	num := new(big.Int).Mul(x, x)
+	num.Mul(num, big.NewInt(3)).Add(num, c.a)
+	return c.line(x, y, x, num, new(big.Int).Lsh(y, 1))
+}
+
+func (c *sampleCurve) Add(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	switch {
+	case x1.Sign() == 0 && y1.Sign() == 0:
+		return new(big.Int).Set(x2), new(big.Int).Set(y2)
+	case x2.Sign() == 0 && y2.Sign() == 0:
+		return new(big.Int).Set(x1), new(big.Int).Set(y1)
+	case x1.Cmp(x2) == 0 && y1.Cmp(y2) == 0:
+		return c.Double(x1, y1)
+	case x1.Cmp(x2) == 0:
+		return new(big.Int), new(big.Int)
+	}
+	return c.line(x1, y1, x2, new(big.Int).Sub(y2, y1), new(big.Int).Sub(x2, x1))
+}
+
+func (c *sampleCurve) ScalarMult(x, y *big.Int, k []byte) (*big.Int, *big.Int) {
+	rx, ry := new(big.Int), new(big.Int)
+	for _, b := range k {
+		for i := 7; i >= 0; i-- {
+			rx, ry = c.Double(rx, ry)
+			if b>>uint(i)&1 == 1 {
+				rx, ry = c.Add(rx, ry, x, y)
+			}
+		}
+	}
+	return rx, ry
+}
+
+func (c *sampleCurve) ScalarBaseMult(k []byte) (*big.Int, *big.Int) {
+	return c.ScalarMult(c.Gx, c.Gy, k)
+}
+
+func sampleKey(t *testing.T, d, x, y string) *sm2.PrivateKey {
+	t.Helper()
+	priv := &sm2.PrivateKey{D: bigHex(d)}
+	priv.Curve = gbSampleCurve
+	priv.X, priv.Y = gbSampleCurve.ScalarBaseMult(priv.D.Bytes())
+	if priv.X.Cmp(bigHex(x)) != 0 || priv.Y.Cmp(bigHex(y)) != 0 {
+		t.Fatalf("unexpected public key for %s", d)
+	}
+	return priv
+}
+
+// GB/T 32918.3 附录 A.2 示例：ZA、ZB 按示例曲线的 a 计算，此处直接取标准给出的值
+func TestSM2KeyExchangeStandardExample(t *testing.T) {
+	a := sampleKey(t, "6FCBA2EF9AE0AB902BC3BDE3FF915D44BA4CC78F88E2F8E7F8996D3B8CCEEDEE",
+		"3099093BF3C137D8FCBBCDF4A2AE50F3B0F216C3122D79425FE03A45DBFE1655",
+		"3DF79E8DAC1CF0ECBAA2F2B49D51A4B387F2EFAF482339086A27A8E05BAED98B")
+	b := sampleKey(t, "5E35D7D3F3C54DBAC72E61819E730B019A84208CA3A35E4C2E353DFCCB2A3B53",
+		"245493D446C38D8CC0F118374690E7DF633A8A4BFB3329B5ECE604B2B4F37F43",
+		"53C0869F4B9E17773DE68FEC45E14904E0DEA45BF6CECF9918C85EA047C60A4C")
+	ra := sampleKey(t, "83A2C9C8B96E5AF70BD480B472409A9A327257F1EBB73F5B073354B248668563",
+		"6CB5633816F4DD560B1DEC458310CBCC6856C09505324A6D23150C408F162BF0",
+		"0D6FCF62F1036C0A1B6DACCF57399223A65F7D7BF2D9637E5BBBEB857961BF1A")
+	rb := sampleKey(t, "33FE21940342161C55619C4A0C060293D543C80AF19748CE176D83477DE71C80",
+		"1799B2A2C778295300D9A2325C686129B8F2B5337B3DCF4514E8BBC19D900EE5",
+		"54C9288C82733EFDF7808AE7F27D0E732F7C73A7D9AC98B7D8740A91D0DB3CF4")
+	za := unhex(t, "E4D1D0C3CA4C7F11BC8FF8CB3F4C02A78F108FA098E51A668487240F75E20F31")
+	zb := unhex(t, "6B4B6D0E276691BD4A11BF72F4FB501AE309FDACB72FA6CC336E6656119ABD67")
+
+	initiator := &SM2KeyExchange{initiator: true, curve: gbSampleCurve, priv: a, peer: &b.PublicKey, eph: ra, za: za, zb: zb, klen: 16}
+	responder := &SM2KeyExchange{curve: gbSampleCurve, priv: b, peer: &a.PublicKey, eph: rb, za: za, zb: zb, klen: 16}
+
+	// B1-B9：响应方算出 KB 与 SB
+	kb, err := responder.Compute(initiator.EphemeralPublic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A4-A10：发起方算出 KA，校验 S1 = SB 并给出 SA
+	ka, err := initiator.Compute(responder.EphemeralPublic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := unhex(t, "55B0AC62A6B927BA23703832C853DED4")
+	if !bytes.Equal(ka, want) || !bytes.Equal(kb, want) {
+		t.Fatalf("KA = %x, KB = %x, want %x", ka, kb, want)
+	}
+	sb := unhex(t, "284C8F198F141B502E81250F1581C7E9EEB4CA6990F9E02DF388B45471F5BC5C")
+	sa := unhex(t, "23444DAF8ED7534366CB901C84B3BDBB63504F4065C1116C91A4C00697E6CF7A")
+	if got := responder.Confirmation(); !bytes.Equal(got, sb) {
+		t.Fatalf("SB = %x, want %x", got, sb)
+	}
+	if !bytes.Equal(initiator.expect, sb) { // S1
+		t.Fatalf("S1 = %x, want %x", initiator.expect, sb)
+	}
+	if got := initiator.Confirmation(); !bytes.Equal(got, sa) {
+		t.Fatalf("SA = %x, want %x", got, sa)
+	}
+	if !bytes.Equal(responder.expect, sa) { // S2
+		t.Fatalf("S2 = %x, want %x", responder.expect, sa)
+	}
+	if err := initiator.Verify(responder.Confirmation()); err != nil {
+		t.Fatal(err)
+	}
+	if err := responder.Verify(initiator.Confirmation()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 推荐曲线上的互通向量（与 emmansun/gmsm 的实现交叉验证），经公开接口并通过 Ephemeral 固定临时密钥
+func TestSM2KeyExchangeVectors(t *testing.T) {
+	for i, v := range []struct {
+		localStatic, localEph, remoteStatic, remoteEph, key string
+	}{
+		{
+			"e04c3fd77408b56a648ad439f673511a2ae248def3bab26bdfc9cdbd0ae9607e",
+			"6fe0bac5b09d3ab10f724638811c34464790520e4604e71e6cb0e5310623b5b1",
+			"7a1136f60d2c5531447e5a3093078c2a505abf74f33aefed927ac0a5b27e7dd7",
+			"d0233bdbb0b8a7bfe1aab66132ef06fc4efaedd5d5000692bc21185242a31f6f",
+			"1ad809ebc56ddda532020c352e1e60b121ebeb7b4e632db4dd90a362cf844f8bba85140e30984ddb581199bf5a9dda22",
+		},
+		{
+			"cb5ac204b38d0e5c9fc38a467075986754018f7dbb7cbbc5b4c78d56a88a8ad8",
+			"1681a66c02b67fdadfc53cba9b417b9499d0159435c86bb8760c3a03ae157539",
+			"4f54b10e0d8e9e2fe5cc79893e37fd0fd990762d1372197ed92dde464b2773ef",
+			"a2fe43dea141e9acc88226eaba8908ad17e81376c92102cb8186e8fef61a8700",
+			"7a103ae61a30ed9df573a5febb35a9609cbed5681bcb98a8545351bf7d6824cc4635df5203712ea506e2e3c4ec9b12e7",
+		},
+		{
+			"ee690a34a779ab48227a2f68b062a80f92e26d82835608dd01b7452f1e4fb296",
+			"2046c6cee085665e9f3abeba41fd38e17a26c08f2f5e8f0e1007afc0bf6a2a5d",
+			"8ef49ea427b13cc31151e1c96ae8a48cb7919063f2d342560fb7eaaffb93d8fe",
+			"9baf8d602e43fbae83fedb7368f98c969d378b8a647318f8cafb265296ae37de",
+			"b18e78e5072f301399dc1f4baf2956c0ed2d5f52f19abb1705131b0865b079031259ee6c629b4faed528bcfa1c5d2cbc",
+		},
+	} {
+		key := func(s string) *sm2.PrivateKey {
+			priv, err := ToSM2(unhex(t, s))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return priv
+		}
+		a, b := key(v.localStatic), key(v.remoteStatic)
+		initiator, err := NewSM2KeyExchange(a, &b.PublicKey, true, SM2KeyExchangeOptions{
+			UID: []byte("Alice"), PeerUID: []byte("Bob"), KeyLen: 48, Ephemeral: key(v.localEph),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		responder, err := NewSM2KeyExchange(b, &a.PublicKey, false, SM2KeyExchangeOptions{
+			UID: []byte("Bob"), PeerUID: []byte("Alice"), KeyLen: 48, Ephemeral: key(v.remoteEph),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		kb, err := responder.Compute(initiator.EphemeralPublic())
+		if err != nil {
+			t.Fatal(err)
+		}
+		ka, err := initiator.Compute(responder.EphemeralPublic())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := unhex(t, v.key); !bytes.Equal(ka, want) || !bytes.Equal(kb, want) {
+			t.Errorf("case %d: KA = %x, KB = %x, want %x", i, ka, kb, want)
+		}
+		if err := initiator.Verify(responder.Confirmation()); err != nil {
+			t.Errorf("case %d: verify SB: %v", i, err)
+		}
+		if err := responder.Verify(initiator.Confirmation()); err != nil {
+			t.Errorf("case %d: verify SA: %v", i, err)
+		}
+		if bytes.Equal(initiator.Confirmation(), responder.Confirmation()) {
+			t.Errorf("case %d: SA equals SB", i)
+		}
+	}
+}
+
+func TestSM2KeyExchangeUIDMismatch(t *testing.T) {
+	a, _ := GenerateSM2Key()
+	b, _ := GenerateSM2Key()
+	initiator, _ := NewSM2KeyExchange(a, &b.PublicKey, true, SM2KeyExchangeOptions{UID: []byte("Alice"), PeerUID: []byte("Bob")})
+	responder, _ := NewSM2KeyExchange(b, &a.PublicKey, false, SM2KeyExchangeOptions{UID: []byte("Bob"), PeerUID: []byte("Mallory")})
+	kb, err := responder.Compute(initiator.EphemeralPublic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ka, err := initiator.Compute(responder.EphemeralPublic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(ka, kb) {
+		t.Fatal("keys agree despite mismatched user IDs")
+	}
+	if err := initiator.Verify(responder.Confirmation()); err != ErrKeyExchangeConfirm {
+		t.Fatalf("Verify = %v, want ErrKeyExchangeConfirm", err)
+	}
+}
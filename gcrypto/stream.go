@@ -0,0 +1,283 @@
+package gcrypto
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// 流式加密格式（STREAM 分段构造，大端序）：
+//
+//	magic "GCS" | version(1) | alg(1) | chunkSize(4) | salt(16) | chunk...
+//
+// 每段为 AEAD(明文段) = 密文 || tag，最后一段可短于 chunkSize（可为空）；
+// 段密钥由 HKDF-SHA256(key, salt) 派生，nonce 为 段序号(11，大端) || 末段标记(1)，
+// 头部作为每段的附加认证数据，因此段的重排、删除、截断与拼接都能被发现
+const (
+	streamVersion    = 1
+	streamSaltLen    = 16
+	streamHeaderLen  = 3 + 1 + 1 + 4 + streamSaltLen
+	streamKeyInfo    = "gcrypto stream v1"
+	streamLastMarker = 1
+
+	DefaultStreamChunkSize = 64 << 10
+	MaxStreamChunkSize     = 16 << 20
+)
+
+var streamMagic = []byte("GCS")
+
+var (
+	ErrStreamFormat    = errors.New("gcrypto: invalid stream")
+	ErrStreamTruncated = errors.New("gcrypto: stream truncated")
+)
+
+// streamAlgs 头部中的算法编号
+var streamAlgs = []AEADAlg{1: AlgAESGCM, 2: AlgChaCha20Poly1305, 3: AlgSM4GCM, 4: AlgSM4CCM}
+
+// StreamOptions 流式加密选项
+type StreamOptions struct {
+	Alg       AEADAlg // 默认 AES-GCM，密钥长度需符合算法要求
+	ChunkSize int     // 明文分段大小，默认 DefaultStreamChunkSize，最大 MaxStreamChunkSize
+}
+
+// EncryptStream 分段加密 src 写入 dst，内存占用与分段大小相当
+func EncryptStream(dst io.Writer, src io.Reader, key []byte, opt ...StreamOptions) error {
+	option := StreamOptions{}
+	if len(opt) > 0 {
+		option = opt[0]
+	}
+	if option.Alg == "" {
+		option.Alg = AlgAESGCM
+	}
+	if option.ChunkSize <= 0 {
+		option.ChunkSize = DefaultStreamChunkSize
+	}
+	if option.ChunkSize > MaxStreamChunkSize {
+		return fmt.Errorf("gcrypto: chunk size %d exceeds %d", option.ChunkSize, MaxStreamChunkSize)
+	}
+	id := streamAlgID(option.Alg)
+	if id == 0 {
+		return fmt.Errorf("%w: %s", ErrUnsupportedAEAD, option.Alg)
+	}
+	header := make([]byte, streamHeaderLen)
+	copy(header, streamMagic)
+	header[3], header[4] = streamVersion, id
+	binary.BigEndian.PutUint32(header[5:9], uint32(option.ChunkSize))
+	if _, err := io.ReadFull(rand.Reader, header[9:]); err != nil {
+		return err
+	}
+	s, err := newStreamCipher(option.Alg, key, header)
+	if err != nil {
+		return err
+	}
+	if _, err := dst.Write(header); err != nil {
+		return err
+	}
+	br := bufio.NewReaderSize(src, option.ChunkSize)
+	buf := make([]byte, option.ChunkSize)
+	for {
+		n, err := io.ReadFull(br, buf)
+		last := false
+		switch err {
+		case nil:
+			// 恰好读满一段时预读一个字节判断是否已到末尾
+			if _, err := br.Peek(1); err == io.EOF {
+				last = true
+			} else if err != nil {
+				return err
+			}
+		case io.EOF, io.ErrUnexpectedEOF:
+			last = true
+		default:
+			return err
+		}
+		if _, err := dst.Write(s.seal(buf[:n], last)); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+	}
+}
+
+// DecryptStream 解密 EncryptStream 的输出写入 dst；每段认证通过后才写出，
+// 出错时 dst 中可能已有部分明文，流被截断时返回 ErrStreamTruncated
+func DecryptStream(dst io.Writer, src io.Reader, key []byte) error {
+	header := make([]byte, streamHeaderLen)
+	if _, err := io.ReadFull(src, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return ErrStreamFormat
+		}
+		return err
+	}
+	if !bytes.Equal(header[:3], streamMagic) || header[3] != streamVersion {
+		return ErrStreamFormat
+	}
+	var alg AEADAlg
+	if int(header[4]) < len(streamAlgs) {
+		alg = streamAlgs[header[4]]
+	}
+	chunkSize := int(binary.BigEndian.Uint32(header[5:9]))
+	if alg == "" || chunkSize <= 0 || chunkSize > MaxStreamChunkSize {
+		return ErrStreamFormat
+	}
+	s, err := newStreamCipher(alg, key, header)
+	if err != nil {
+		return err
+	}
+	size := chunkSize + s.aead.Overhead()
+	br := bufio.NewReaderSize(src, size)
+	buf := make([]byte, size)
+	for {
+		n, err := io.ReadFull(br, buf)
+		last := false
+		switch err {
+		case nil:
+			if _, err := br.Peek(1); err == io.EOF {
+				last = true
+			} else if err != nil {
+				return err
+			}
+		case io.EOF:
+			// 末段缺失
+			return ErrStreamTruncated
+		case io.ErrUnexpectedEOF:
+			last = true
+		default:
+			return err
+		}
+		plain, err := s.open(buf[:n], last)
+		if err != nil {
+			return err
+		}
+		if _, err := dst.Write(plain); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+	}
+}
+
+// EncryptFile 流式加密文件，先写临时文件再原子替换 dst
+func EncryptFile(src, dst string, key []byte, opt ...StreamOptions) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	return writeFileAtomic(dst, func(w io.Writer) error {
+		return EncryptStream(w, in, key, opt...)
+	})
+}
+
+// DecryptFile 流式解密文件，只有整个文件认证通过才会生成 dst
+func DecryptFile(src, dst string, key []byte) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	return writeFileAtomic(dst, func(w io.Writer) error {
+		return DecryptStream(w, in, key)
+	})
+}
+
+// writeFileAtomic 在 path 所在目录写临时文件，成功后 fsync 并重命名为 path，失败时删除临时文件
+func writeFileAtomic(path string, write func(w io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	bw := bufio.NewWriterSize(tmp, 256<<10)
+	if err := write(bw); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func streamAlgID(alg AEADAlg) byte {
+	for i, a := range streamAlgs {
+		if a != "" && a == alg {
+			return byte(i)
+		}
+	}
+	return 0
+}
+
+// streamCipher 单个流的分段加解密状态
+type streamCipher struct {
+	aead   cipher.AEAD
+	header []byte
+	seq    uint64
+	out    []byte
+}
+
+func newStreamCipher(alg AEADAlg, key, header []byte) (*streamCipher, error) {
+	sub, err := hkdf.Key(sha256.New, key, header[9:], streamKeyInfo, len(key))
+	if err != nil {
+		return nil, err
+	}
+	a, err := NewAEAD(alg, sub)
+	if err != nil {
+		return nil, err
+	}
+	if a.NonceSize() != 12 {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAEAD, alg)
+	}
+	return &streamCipher{aead: a, header: header}, nil
+}
+
+func (s *streamCipher) nonce(last bool) []byte {
+	var n [12]byte
+	binary.BigEndian.PutUint64(n[3:11], s.seq)
+	if last {
+		n[11] = streamLastMarker
+	}
+	return n[:]
+}
+
+func (s *streamCipher) seal(plain []byte, last bool) []byte {
+	s.out = s.aead.Seal(s.out[:0], s.nonce(last), plain, s.header)
+	s.seq++
+	return s.out
+}
+
+// open 解密一段；按预期标记认证失败时再按相反标记尝试，以区分截断与篡改
+func (s *streamCipher) open(data []byte, last bool) ([]byte, error) {
+	plain, err := s.aead.Open(s.out[:0], s.nonce(last), data, s.header)
+	if err != nil {
+		if _, err := s.aead.Open(nil, s.nonce(!last), data, s.header); err == nil {
+			if last {
+				return nil, ErrStreamTruncated
+			}
+			return nil, fmt.Errorf("%w: trailing data after final chunk", ErrStreamFormat)
+		}
+		return nil, ErrDecrypt
+	}
+	s.out = plain
+	s.seq++
+	return plain, nil
+}
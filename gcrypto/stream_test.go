@@ -0,0 +1,217 @@
+package gcrypto
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+const testChunk = 64
+
+func encryptStream(t *testing.T, plain, key []byte, opt StreamOptions) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := EncryptStream(&buf, bytes.NewReader(plain), key, opt); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func decryptStream(data, key []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	err := DecryptStream(&buf, bytes.NewReader(data), key)
+	return buf.Bytes(), err
+}
+
+func pattern(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i * 7)
+	}
+	return b
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	for _, alg := range []AEADAlg{AlgAESGCM, AlgChaCha20Poly1305, AlgSM4GCM, AlgSM4CCM} {
+		key := make([]byte, 32)
+		if alg == AlgSM4GCM || alg == AlgSM4CCM {
+			key = key[:16]
+		}
+		for _, n := range []int{0, 1, testChunk - 1, testChunk, 2 * testChunk, 2*testChunk + 1} {
+			plain := pattern(n)
+			data := encryptStream(t, plain, key, StreamOptions{Alg: alg, ChunkSize: testChunk})
+			// 空输入与整段倍数都不额外追加空段
+			chunks := max(1, (n+testChunk-1)/testChunk)
+			if want := streamHeaderLen + n + chunks*16; len(data) != want {
+				t.Errorf("%s/%d: stream is %d bytes, want %d", alg, n, len(data), want)
+			}
+			got, err := decryptStream(data, key)
+			if err != nil || !bytes.Equal(got, plain) {
+				t.Errorf("%s/%d: DecryptStream = %d bytes, %v", alg, n, len(got), err)
+			}
+		}
+	}
+}
+
+func TestStreamDefaults(t *testing.T) {
+	key := make([]byte, 32)
+	plain := pattern(DefaultStreamChunkSize + 10)
+	// 逐字节读取的源也应按整段加密
+	var buf bytes.Buffer
+	if err := EncryptStream(&buf, iotest.OneByteReader(bytes.NewReader(plain)), key); err != nil {
+		t.Fatal(err)
+	}
+	if want := streamHeaderLen + len(plain) + 2*16; buf.Len() != want {
+		t.Errorf("stream is %d bytes, want %d", buf.Len(), want)
+	}
+	var out bytes.Buffer
+	if err := DecryptStream(&out, iotest.HalfReader(&buf), key); err != nil || !bytes.Equal(out.Bytes(), plain) {
+		t.Errorf("DecryptStream = %d bytes, %v", out.Len(), err)
+	}
+	if err := EncryptStream(&buf, bytes.NewReader(nil), key, StreamOptions{ChunkSize: MaxStreamChunkSize + 1}); err == nil {
+		t.Error("oversized chunk accepted")
+	}
+	if err := EncryptStream(&buf, bytes.NewReader(nil), key, StreamOptions{Alg: "AES-OCB"}); !errors.Is(err, ErrUnsupportedAEAD) {
+		t.Errorf("unsupported algorithm: %v", err)
+	}
+	if err := EncryptStream(&buf, bytes.NewReader(nil), key[:15]); err == nil {
+		t.Error("short key accepted")
+	}
+	if err := EncryptStream(&buf, iotest.ErrReader(errors.New("boom")), key); err == nil || err.Error() != "boom" {
+		t.Errorf("read error: %v", err)
+	}
+}
+
+func TestStreamTampering(t *testing.T) {
+	key := make([]byte, 32)
+	opt := StreamOptions{ChunkSize: testChunk}
+	const chunk = testChunk + 16
+	three := encryptStream(t, pattern(2*testChunk+1), key, opt) // 两个整段加一个 1 字节末段
+	exact := encryptStream(t, pattern(2*testChunk), key, opt)   // 末段为整段
+	other := encryptStream(t, pattern(2*testChunk+1), key, opt) // 同一密钥的另一个流
+	swapped := append([]byte(nil), three...)
+	copy(swapped[streamHeaderLen:], three[streamHeaderLen+chunk:streamHeaderLen+2*chunk])
+	copy(swapped[streamHeaderLen+chunk:], three[streamHeaderLen:streamHeaderLen+chunk])
+	flip := func(data []byte, i int) []byte {
+		b := append([]byte(nil), data...)
+		b[i] ^= 1
+		return b
+	}
+	for _, c := range []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"empty", nil, ErrStreamFormat},
+		{"short header", three[:streamHeaderLen-1], ErrStreamFormat},
+		{"bad magic", flip(three, 0), ErrStreamFormat},
+		{"bad version", flip(three, 3), ErrStreamFormat},
+		{"bad algorithm", flip(three, 4), ErrStreamFormat},
+		{"header only", three[:streamHeaderLen], ErrStreamTruncated},
+		{"final chunk dropped", three[:streamHeaderLen+2*chunk], ErrStreamTruncated},
+		{"final full chunk dropped", exact[:streamHeaderLen+chunk], ErrStreamTruncated},
+		{"cut inside chunk", three[:streamHeaderLen+chunk+10], ErrDecrypt},
+		{"cut inside final chunk", three[:len(three)-1], ErrDecrypt},
+		{"trailing data", append(append([]byte(nil), exact...), 'x'), ErrStreamFormat},
+		{"trailing chunk", append(append([]byte(nil), exact...), exact[streamHeaderLen:streamHeaderLen+chunk]...), ErrStreamFormat},
+		{"swapped chunks", swapped, ErrDecrypt},
+		{"flipped ciphertext", flip(three, streamHeaderLen+5), ErrDecrypt},
+		{"flipped salt", flip(three, 10), ErrDecrypt},
+		{"changed chunk size", flip(three, 8), ErrDecrypt},
+		{"spliced streams", append(append([]byte(nil), three[:streamHeaderLen+chunk]...), other[streamHeaderLen+chunk:]...), ErrDecrypt},
+	} {
+		_, err := decryptStream(c.data, key)
+		if !errors.Is(err, c.want) {
+			t.Errorf("%s: DecryptStream = %v, want %v", c.name, err, c.want)
+		}
+	}
+	wrong := bytes.Repeat([]byte{1}, 32)
+	if _, err := decryptStream(three, wrong); err != ErrDecrypt {
+		t.Errorf("wrong key: %v", err)
+	}
+	if _, err := decryptStream(three, key[:16]); err != ErrDecrypt {
+		t.Errorf("wrong key length: %v", err)
+	}
+}
+
+func TestStreamFile(t *testing.T) {
+	dir := t.TempDir()
+	key := make([]byte, 32)
+	src, enc, dst := filepath.Join(dir, "plain"), filepath.Join(dir, "plain.enc"), filepath.Join(dir, "plain.out")
+	plain := pattern(3*testChunk + 5)
+	if err := os.WriteFile(src, plain, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := EncryptFile(src, enc, key, StreamOptions{ChunkSize: testChunk}); err != nil {
+		t.Fatal(err)
+	}
+	if err := DecryptFile(enc, dst, key); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := os.ReadFile(dst); err != nil || !bytes.Equal(got, plain) {
+		t.Fatalf("DecryptFile wrote %d bytes, %v", len(got), err)
+	}
+	// 认证失败时不生成 dst，也不留下临时文件
+	data, _ := os.ReadFile(enc)
+	for name, bad := range map[string][]byte{
+		"truncated": data[:len(data)-21],
+		"tampered":  append(data[:len(data)-1:len(data)-1], data[len(data)-1]^1),
+	} {
+		if err := os.WriteFile(enc, bad, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		out := filepath.Join(dir, name+".out")
+		if err := DecryptFile(enc, out, key); err == nil {
+			t.Errorf("%s: DecryptFile succeeded", name)
+		}
+		if _, err := os.Stat(out); !os.IsNotExist(err) {
+			t.Errorf("%s: dst exists: %v", name, err)
+		}
+	}
+	// 已有的 dst 在失败时保持不变
+	if err := DecryptFile(enc, dst, bytes.Repeat([]byte{1}, 32)); err != ErrDecrypt {
+		t.Errorf("wrong key: %v", err)
+	}
+	if got, _ := os.ReadFile(dst); !bytes.Equal(got, plain) {
+		t.Error("existing dst overwritten on failure")
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if strings.Contains(e.Name(), ".tmp-") {
+			t.Errorf("temporary file %s left behind", e.Name())
+		}
+	}
+	if err := EncryptFile(filepath.Join(dir, "missing"), enc, key); !os.IsNotExist(err) {
+		t.Errorf("missing source: %v", err)
+	}
+}
+
+func TestHashReaders(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		fn    func(r io.Reader) (string, error)
+		bytes func(b []byte) string
+		abc   string
+	}{
+		{"MD5", MD5Reader, MD5Bytes, "900150983cd24fb0d6963f7d28e17f72"},
+		{"SHA1", SHA1Reader, SHA1Bytes, "a9993e364706816aba3e25717850c26c9cd0d89d"},
+		{"SHA256", SHA256Reader, SHA256Bytes, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{"SM3", SM3Reader, SM3Bytes, "66c7f0f462eeedd9d1f2d46bdc10e4e24167c4875cf2f7a2297da02b8f4ba8e0"},
+	} {
+		if got, err := c.fn(strings.NewReader("abc")); err != nil || got != c.abc {
+			t.Errorf("%s(abc) = %s, %v", c.name, got, err)
+		}
+		big := pattern(100000)
+		if got, err := c.fn(iotest.HalfReader(bytes.NewReader(big))); err != nil || got != c.bytes(big) {
+			t.Errorf("%s: reader and bytes digests differ: %s, %v", c.name, got, err)
+		}
+		if _, err := c.fn(iotest.ErrReader(errors.New("boom"))); err == nil {
+			t.Errorf("%s: read error ignored", c.name)
+		}
+	}
+}
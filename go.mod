@@ -3,8 +3,12 @@ module github.com/hellobchain/gotool
 go 1.25
 
 require (
+	github.com/andybalholm/brotli v1.2.6
 	github.com/fsnotify/fsnotify v1.9.0
+	github.com/klauspost/compress v1.20.1
+	github.com/pierrec/lz4/v4 v4.1.33
 	github.com/tjfoc/gmsm v1.4.1
+	golang.org/x/crypto v0.47.0
 )
 
-require golang.org/x/sys v0.13.0 // indirect
+require golang.org/x/sys v0.40.0 // indirect
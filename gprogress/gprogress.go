@@ -27,6 +27,24 @@ func (b *Bar) Add(n int64) {
 	b.render()
 }
 
+// Set 设置当前进度
+func (b *Bar) Set(n int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.current = n
+	if b.current > b.total {
+		b.current = b.total
+	}
+	b.render()
+}
+
+// SetTotal 修改总量（总量事先未知时使用）
+func (b *Bar) SetTotal(total int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.total = total
+}
+
 func (b *Bar) Done() {
 	b.mu.Lock()
 	defer b.mu.Unlock()
@@ -36,7 +54,10 @@ func (b *Bar) Done() {
 }
 
 func (b *Bar) render() {
-	rate := float64(b.current) / float64(b.total)
+	rate := 1.0
+	if b.total > 0 {
+		rate = float64(b.current) / float64(b.total)
+	}
 	percent := int(rate * 100)
 	filled := int(rate * 50)
 	bar := strings.Repeat("█", filled) + strings.Repeat("░", 50-filled)
@@ -0,0 +1,195 @@
+package gschedule
+
+import (
+	"time"
+)
+
+// maxCombineSteps 组合调度查找匹配时的最大迭代次数，超过视为不再触发
+const maxCombineSteps = 10000
+
+// Calendar 日历，按日期判断某天是否包含在内（以 t 所在时区的日期为准）
+type Calendar interface {
+	Contains(t time.Time) bool
+}
+
+// CalendarFunc 函数式 Calendar
+type CalendarFunc func(t time.Time) bool
+
+func (f CalendarFunc) Contains(t time.Time) bool { return f(t) }
+
+// Weekends 周末日历，days 为空时为周六、周日
+func Weekends(days ...time.Weekday) Calendar {
+	if len(days) == 0 {
+		days = []time.Weekday{time.Saturday, time.Sunday}
+	}
+	var mask uint8
+	for _, d := range days {
+		mask |= 1 << d
+	}
+	return CalendarFunc(func(t time.Time) bool { return mask&(1<<t.Weekday()) != 0 })
+}
+
+// Intersect 同时是 a 与 b 触发时间的时刻
+func Intersect(a, b Schedule) Schedule {
+	return intersect{a: a, b: b}
+}
+
+type intersect struct{ a, b Schedule }
+
+func (s intersect) Next(t time.Time) time.Time {
+	ta, tb := s.a.Next(t), s.b.Next(t)
+	for range maxCombineSteps {
+		if ta.IsZero() || tb.IsZero() {
+			return time.Time{}
+		}
+		switch {
+		case ta.Equal(tb):
+			return ta
+		case ta.Before(tb):
+			ta = s.a.Next(tb.Add(-time.Nanosecond))
+		default:
+			tb = s.b.Next(ta.Add(-time.Nanosecond))
+		}
+	}
+	return time.Time{}
+}
+
+// Union 任一调度的触发时间，相同时刻只触发一次
+func Union(scheds ...Schedule) Schedule {
+	return union(scheds)
+}
+
+type union []Schedule
+
+func (u union) Next(t time.Time) time.Time {
+	var next time.Time
+	for _, s := range u {
+		if n := s.Next(t); !n.IsZero() && (next.IsZero() || n.Before(next)) {
+			next = n
+		}
+	}
+	return next
+}
+
+// Except s 的触发时间中排除落在 cal 内的日期，如 Except(cron, holidays) 节假日不执行
+func Except(s Schedule, cal Calendar) Schedule {
+	return filter{s: s, cal: cal, keep: false}
+}
+
+// Only s 的触发时间中只保留落在 cal 内的日期
+func Only(s Schedule, cal Calendar) Schedule {
+	return filter{s: s, cal: cal, keep: true}
+}
+
+type filter struct {
+	s    Schedule
+	cal  Calendar
+	keep bool
+}
+
+func (f filter) Next(t time.Time) time.Time {
+	for range maxCombineSteps {
+		t = f.s.Next(t)
+		if t.IsZero() || f.cal.Contains(t) == f.keep {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Offset 将 s 的触发时间整体平移 d，如 Offset(cron, -10*time.Minute) 提前 10 分钟
+func Offset(s Schedule, d time.Duration) Schedule {
+	return offset{s: s, d: d}
+}
+
+type offset struct {
+	s Schedule
+	d time.Duration
+}
+
+func (o offset) Next(t time.Time) time.Time {
+	n := o.s.Next(t.Add(-o.d))
+	if n.IsZero() {
+		return n
+	}
+	return n.Add(o.d)
+}
+
+// NthWeekdayOfMonth 每月第 n 个星期 wd 的 hour:minute 触发，n 为负数时从月末倒数（-1 为最后一个）
+// loc 为 nil 时使用 time.Local；当月不存在第 n 个时跳过该月
+func NthWeekdayOfMonth(n int, wd time.Weekday, hour, minute int, loc *time.Location) Schedule {
+	return monthly{hour: hour, minute: minute, loc: loc, pick: func(y int, m time.Month) (int, bool) {
+		last := daysIn(y, m)
+		var day int
+		if n > 0 {
+			first := time.Date(y, m, 1, 0, 0, 0, 0, time.UTC).Weekday()
+			day = 1 + int(wd-first+7)%7 + 7*(n-1)
+		} else {
+			lastWd := time.Date(y, m, last, 0, 0, 0, 0, time.UTC).Weekday()
+			day = last - int(lastWd-wd+7)%7 + 7*(n+1)
+		}
+		return day, n != 0 && day >= 1 && day <= last
+	}}
+}
+
+// NthBusinessDayOfMonth 每月第 n 个工作日的 hour:minute 触发，n 为负数时从月末倒数（-1 为最后一个工作日）
+// offDays 为休息日日历（如 *HolidayCalendar），nil 时只排除周末；loc 为 nil 时使用 time.Local
+func NthBusinessDayOfMonth(n int, offDays Calendar, hour, minute int, loc *time.Location) Schedule {
+	if offDays == nil {
+		offDays = Weekends()
+	}
+	s := monthly{hour: hour, minute: minute, loc: loc}
+	s.pick = func(y int, m time.Month) (int, bool) {
+		last, step, day := daysIn(y, m), 1, 1
+		if n < 0 {
+			step, day = -1, daysIn(y, m)
+		}
+		k := 0
+		for ; day >= 1 && day <= last; day += step {
+			if offDays.Contains(time.Date(y, m, day, 12, 0, 0, 0, s.location())) {
+				continue
+			}
+			if k++; k == n || -k == n {
+				return day, true
+			}
+		}
+		return 0, false
+	}
+	return s
+}
+
+// LastBusinessDayOfMonth 每月最后一个工作日的 hour:minute 触发
+func LastBusinessDayOfMonth(offDays Calendar, hour, minute int, loc *time.Location) Schedule {
+	return NthBusinessDayOfMonth(-1, offDays, hour, minute, loc)
+}
+
+// monthly 每月由 pick 选出一天，在其 hour:minute 触发；夏令时语义同 Cron
+type monthly struct {
+	hour, minute int
+	loc          *time.Location
+	pick         func(y int, m time.Month) (int, bool)
+}
+
+func (s monthly) location() *time.Location {
+	if s.loc == nil {
+		return time.Local
+	}
+	return s.loc
+}
+
+func (s monthly) Next(t time.Time) time.Time {
+	loc := s.location()
+	lt := t.In(loc)
+	y, m := lt.Year(), lt.Month()
+	for i := 0; i < 12*maxCronYears; i++ {
+		if day, ok := s.pick(y, m); ok {
+			if c := wallToTime(wallDate(y, m, day, s.hour, s.minute, 0), loc); c.After(t) {
+				return c
+			}
+		}
+		if m++; m > time.December {
+			y, m = y+1, time.January
+		}
+	}
+	return time.Time{}
+}
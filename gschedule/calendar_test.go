@@ -0,0 +1,123 @@
+package gschedule
+
+import (
+	"testing"
+	"time"
+)
+
+// take 依次取 s 在 t 之后的 n 次触发时间
+func take(s Schedule, t time.Time, n int) []time.Time {
+	var res []time.Time
+	for range n {
+		if t = s.Next(t); t.IsZero() {
+			break
+		}
+		res = append(res, t)
+	}
+	return res
+}
+
+func sameTimes(a, b []time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// chinaOctober2024 2024 年国庆：10 月 1 日至 7 日放假，9 月 29 日（周日）与 10 月 12 日（周六）上班
+func chinaOctober2024() *HolidayCalendar {
+	c := NewHolidayCalendar(nil)
+	for d := 1; d <= 7; d++ {
+		c.AddHoliday(time.Date(2024, 10, d, 0, 0, 0, 0, time.UTC), "国庆节")
+	}
+	c.AddWorkday(time.Date(2024, 9, 29, 0, 0, 0, 0, time.UTC), "国庆节补班")
+	c.AddWorkday(time.Date(2024, 10, 12, 0, 0, 0, 0, time.UTC), "国庆节补班")
+	return c
+}
+
+func TestCombinators(t *testing.T) {
+	utc := func(mo time.Month, d, h, mi int) time.Time { return time.Date(2024, mo, d, h, mi, 0, 0, time.UTC) }
+	daily := mustCron(t, "0 9 * * *")
+	never := mustCron(t, "0 0 30 2 *")
+	for _, c := range []struct {
+		name string
+		s    Schedule
+		from time.Time
+		want []time.Time
+	}{
+		{"intersect", Intersect(mustCron(t, "0 */2 * * *"), mustCron(t, "0 */3 * * *")), utc(1, 1, 0, 0),
+			[]time.Time{utc(1, 1, 6, 0), utc(1, 1, 12, 0), utc(1, 1, 18, 0), utc(1, 2, 0, 0)}},
+		{"intersect weekday", Intersect(daily, mustCron(t, "0 * * * MON")), utc(1, 1, 12, 0),
+			[]time.Time{utc(1, 8, 9, 0), utc(1, 15, 9, 0)}},
+		{"intersect disjoint", Intersect(mustCron(t, "0 0 * * 1"), mustCron(t, "0 0 * * 2")), utc(1, 1, 0, 0), nil},
+		{"intersect never", Intersect(daily, never), utc(1, 1, 0, 0), nil},
+		{"union", Union(daily, mustCron(t, "30 9 * * *"), daily), utc(1, 1, 0, 0),
+			[]time.Time{utc(1, 1, 9, 0), utc(1, 1, 9, 30), utc(1, 2, 9, 0)}},
+		{"union never", Union(never, daily), utc(1, 1, 10, 0), []time.Time{utc(1, 2, 9, 0)}},
+		{"union empty", Union(), utc(1, 1, 0, 0), nil},
+		// 2024-01-05 为周五
+		{"except weekends", Except(daily, Weekends()), utc(1, 5, 0, 0),
+			[]time.Time{utc(1, 5, 9, 0), utc(1, 8, 9, 0), utc(1, 9, 9, 0)}},
+		{"only weekends", Only(daily, Weekends()), utc(1, 5, 0, 0),
+			[]time.Time{utc(1, 6, 9, 0), utc(1, 7, 9, 0), utc(1, 13, 9, 0)}},
+		{"only sunday", Only(daily, Weekends(time.Sunday)), utc(1, 5, 0, 0), []time.Time{utc(1, 7, 9, 0), utc(1, 14, 9, 0)}},
+		{"except all", Except(daily, CalendarFunc(func(time.Time) bool { return true })), utc(1, 1, 0, 0), nil},
+		{"except holidays", Except(daily, chinaOctober2024()), utc(9, 27, 12, 0),
+			[]time.Time{utc(9, 29, 9, 0), utc(9, 30, 9, 0), utc(10, 8, 9, 0)}},
+		{"offset earlier", Offset(daily, -10*time.Minute), utc(1, 1, 8, 45),
+			[]time.Time{utc(1, 1, 8, 50), utc(1, 2, 8, 50)}},
+		{"offset boundary", Offset(daily, -10*time.Minute), utc(1, 1, 8, 50), []time.Time{utc(1, 2, 8, 50)}},
+		{"offset later", Offset(daily, 30*time.Minute), utc(1, 1, 9, 10), []time.Time{utc(1, 1, 9, 30), utc(1, 2, 9, 30)}},
+		{"offset never", Offset(never, time.Hour), utc(1, 1, 0, 0), nil},
+	} {
+		if got := take(c.s, c.from, max(len(c.want), 1)); !sameTimes(got, c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestMonthlySchedules(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	utc := func(mo time.Month, d, h, mi int) time.Time { return time.Date(2024, mo, d, h, mi, 0, 0, time.UTC) }
+	start := utc(1, 1, 0, 0)
+	for _, c := range []struct {
+		name string
+		s    Schedule
+		from time.Time
+		want []time.Time
+	}{
+		{"second tuesday", NthWeekdayOfMonth(2, time.Tuesday, 10, 0, time.UTC), start,
+			[]time.Time{utc(1, 9, 10, 0), utc(2, 13, 10, 0), utc(3, 12, 10, 0)}},
+		{"last friday", NthWeekdayOfMonth(-1, time.Friday, 17, 30, time.UTC), start,
+			[]time.Time{utc(1, 26, 17, 30), utc(2, 23, 17, 30), utc(3, 29, 17, 30)}},
+		{"fifth friday skips months", NthWeekdayOfMonth(5, time.Friday, 0, 0, time.UTC), start,
+			[]time.Time{utc(3, 29, 0, 0), utc(5, 31, 0, 0), utc(8, 30, 0, 0), utc(11, 29, 0, 0)}},
+		{"same day later time", NthWeekdayOfMonth(2, time.Tuesday, 10, 0, time.UTC), utc(1, 9, 9, 0), []time.Time{utc(1, 9, 10, 0)}},
+		{"same day passed", NthWeekdayOfMonth(2, time.Tuesday, 10, 0, time.UTC), utc(1, 9, 10, 0), []time.Time{utc(2, 13, 10, 0)}},
+		{"zero", NthWeekdayOfMonth(0, time.Monday, 0, 0, time.UTC), start, nil},
+		// 2024-03-10 为 3 月第二个周日，02:30 落在夏令时跳过区间
+		{"dst gap", NthWeekdayOfMonth(2, time.Sunday, 2, 30, ny), utc(2, 15, 0, 0),
+			[]time.Time{time.Date(2024, 3, 10, 3, 30, 0, 0, ny), time.Date(2024, 4, 14, 2, 30, 0, 0, ny)}},
+		{"first business day", NthBusinessDayOfMonth(1, nil, 9, 0, time.UTC), utc(5, 15, 0, 0),
+			[]time.Time{utc(6, 3, 9, 0), utc(7, 1, 9, 0)}},
+		{"last business day", LastBusinessDayOfMonth(nil, 18, 0, time.UTC), utc(6, 1, 0, 0),
+			[]time.Time{utc(6, 28, 18, 0), utc(7, 31, 18, 0), utc(8, 30, 18, 0)}},
+		{"first business day after holidays", NthBusinessDayOfMonth(1, chinaOctober2024(), 9, 0, time.UTC), utc(9, 15, 0, 0),
+			[]time.Time{utc(10, 8, 9, 0)}},
+		{"second to last with make-up workday", NthBusinessDayOfMonth(-2, chinaOctober2024(), 9, 0, time.UTC), utc(9, 1, 0, 0),
+			[]time.Time{utc(9, 29, 9, 0)}},
+		{"more business days than the month has", NthBusinessDayOfMonth(25, nil, 9, 0, time.UTC), start, nil},
+	} {
+		if got := take(c.s, c.from, max(len(c.want), 1)); !sameTimes(got, c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,666 @@
+package gschedule
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron cron 表达式
+//
+// 支持 5 位（分 时 日 月 周）与 6 位（秒 分 时 日 月 周）：
+//   - * ? , - / 以及 a-b/n、a/n 步进
+//   - 月份与星期名称：JAN-DEC、SUN-SAT（不区分大小写），星期 7 同 0 表示周日
+//   - 日：L 月末、L-n 月末前 n 天、nW 离 n 号最近的工作日、LW 月末最后一个工作日
+//   - 周：nL 当月最后一个星期 n、n#k 当月第 k 个星期 n
+//   - 宏：@yearly @annually @monthly @weekly @daily @midnight @hourly @every <duration>
+//
+// 日与周同时受限时按标准 cron 规则取并集（任一匹配即可）
+//
+// 时区：表达式可带 CRON_TZ=Asia/Shanghai（或 TZ=）前缀，也可通过 CronOptions.Location 指定，
+// 都未指定时按传入时间自身的时区计算。
+// 夏令时：跳过区间内的时刻顺延到跳变之后（多个时刻落入同一区间时只触发一次）；
+// 重复出现的时刻只在较早的一次触发，如 "30 1 * * *" 在回拨当天只执行一次
+type Cron struct {
+	expr string
+	loc  *time.Location
+	sec  uint64
+	min  uint64
+	hour uint64
+	dom  uint64
+	mon  uint64
+	dow  uint64
+
+	domStar bool
+	dowStar bool
+	domL    []int    // L、L-n：距月末的天数
+	domW    []int    // nW
+	domLW   bool     // LW
+	dowL    []int    // nL
+	dowNth  [][2]int // n#k：{星期, 第几个}
+
+	every time.Duration // @every
+}
+
+// CronOptions cron 选项
+type CronOptions struct {
+	// 计算所用时区，表达式中的 CRON_TZ= 优先
+	Location *time.Location
+}
+
+// maxCronYears 查找匹配的年份上限，覆盖 "2 月第 5 个周一" 这类 28 年一遇的表达式
+const maxCronYears = 50
+
+// CronError cron 解析错误，指出出错的字段
+type CronError struct {
+	Expr  string
+	Field string
+	Value string
+	Msg   string
+}
+
+func (e *CronError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("gschedule: cron %q: %s", e.Expr, e.Msg)
+	}
+	return fmt.Sprintf("gschedule: cron %q: field %s %q: %s", e.Expr, e.Field, e.Value, e.Msg)
+}
+
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	fieldSec   = cronField{name: "second", min: 0, max: 59}
+	fieldMin   = cronField{name: "minute", min: 0, max: 59}
+	fieldHour  = cronField{name: "hour", min: 0, max: 23}
+	fieldDom   = cronField{name: "day-of-month", min: 1, max: 31}
+	fieldMonth = cronField{name: "month", min: 1, max: 12, names: map[string]int{
+		"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+		"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+	}}
+	fieldDow = cronField{name: "day-of-week", min: 0, max: 7, names: map[string]int{
+		"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+	}}
+)
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 0 1 1 *",
+	"@annually": "0 0 0 1 1 *",
+	"@monthly":  "0 0 0 1 * *",
+	"@weekly":   "0 0 0 * * 0",
+	"@daily":    "0 0 0 * * *",
+	"@midnight": "0 0 0 * * *",
+	"@hourly":   "0 0 * * * *",
+}
+
+// NewCron 解析 cron 表达式
+// 例："* 2-4 * * 1-5"、"0 30 9 * * MON-FRI"、"0 0 L * *"、"0 10 * * 5#3"、"@every 90s"、
+// "CRON_TZ=Asia/Shanghai 0 9 * * *"
+func NewCron(expr string, opt ...CronOptions) (*Cron, error) {
+	var loc *time.Location
+	if len(opt) > 0 {
+		loc = opt[0].Location
+	}
+	s := strings.TrimSpace(expr)
+	if strings.HasPrefix(s, "CRON_TZ=") || strings.HasPrefix(s, "TZ=") {
+		tz, rest, _ := strings.Cut(s, " ")
+		_, name, _ := strings.Cut(tz, "=")
+		l, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, &CronError{Expr: expr, Field: "CRON_TZ", Value: name, Msg: err.Error()}
+		}
+		loc, s = l, strings.TrimSpace(rest)
+	}
+	c, err := parseSpec(s)
+	if err != nil {
+		if ce, ok := err.(*CronError); ok {
+			ce.Expr = expr
+		}
+		return nil, err
+	}
+	c.expr, c.loc = expr, loc
+	return c, nil
+}
+
+// parseSpec 解析去掉时区前缀后的表达式或宏
+func parseSpec(s string) (*Cron, error) {
+	if !strings.HasPrefix(s, "@") {
+		return parseCron(s)
+	}
+	fields := strings.Fields(s)
+	if strings.ToLower(fields[0]) == "@every" {
+		if len(fields) != 2 {
+			return nil, &CronError{Msg: "@every needs a duration"}
+		}
+		d, err := time.ParseDuration(fields[1])
+		if err != nil || d <= 0 {
+			return nil, &CronError{Field: "@every", Value: fields[1], Msg: "invalid duration"}
+		}
+		return &Cron{every: d}, nil
+	}
+	m, ok := cronMacros[strings.ToLower(fields[0])]
+	if !ok || len(fields) != 1 {
+		return nil, &CronError{Msg: fmt.Sprintf("unknown macro %s", s)}
+	}
+	return parseCron(m)
+}
+
+func parseCron(expr string) (*Cron, error) {
+	fields := strings.Fields(expr)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, &CronError{Expr: expr, Msg: fmt.Sprintf("need 5 or 6 fields, got %d", len(fields))}
+	}
+	c := &Cron{}
+	var err error
+	if c.sec, _, err = parseCronField(expr, fields[0], fieldSec); err != nil {
+		return nil, err
+	}
+	if c.min, _, err = parseCronField(expr, fields[1], fieldMin); err != nil {
+		return nil, err
+	}
+	if c.hour, _, err = parseCronField(expr, fields[2], fieldHour); err != nil {
+		return nil, err
+	}
+	if err = c.parseDom(expr, fields[3]); err != nil {
+		return nil, err
+	}
+	if c.mon, _, err = parseCronField(expr, fields[4], fieldMonth); err != nil {
+		return nil, err
+	}
+	if err = c.parseDow(expr, fields[5]); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// parseDom 解析日字段，额外支持 L、L-n、nW、LW
+func (c *Cron) parseDom(expr, field string) error {
+	var plain []string
+	for _, part := range strings.Split(field, ",") {
+		p := strings.ToUpper(part)
+		switch {
+		case p == "L":
+			c.domL = append(c.domL, 0)
+		case p == "LW":
+			c.domLW = true
+		case strings.HasPrefix(p, "L-"):
+			n, err := strconv.Atoi(p[2:])
+			if err != nil || n < 1 || n > 30 {
+				return &CronError{Expr: expr, Field: fieldDom.name, Value: part, Msg: "L-n offset must be in [1,30]"}
+			}
+			c.domL = append(c.domL, n)
+		case strings.HasSuffix(p, "W"):
+			n, err := strconv.Atoi(p[:len(p)-1])
+			if err != nil || n < 1 || n > 31 {
+				return &CronError{Expr: expr, Field: fieldDom.name, Value: part, Msg: "nW day must be in [1,31]"}
+			}
+			c.domW = append(c.domW, n)
+		default:
+			plain = append(plain, part)
+		}
+	}
+	if len(plain) == 0 {
+		return nil
+	}
+	var err error
+	c.dom, c.domStar, err = parseCronField(expr, strings.Join(plain, ","), fieldDom)
+	if err == nil && c.domStar && (len(c.domL) > 0 || len(c.domW) > 0 || c.domLW) {
+		return &CronError{Expr: expr, Field: fieldDom.name, Value: field, Msg: "* cannot be combined with L or W"}
+	}
+	return err
+}
+
+// parseDow 解析周字段，额外支持 nL、n#k；7 视为 0
+func (c *Cron) parseDow(expr, field string) error {
+	var plain []string
+	for _, part := range strings.Split(field, ",") {
+		p := strings.ToUpper(part)
+		switch {
+		case p == "L":
+			c.dowL = append(c.dowL, 6)
+		case strings.HasSuffix(p, "L"):
+			d, err := parseCronValue(p[:len(p)-1], fieldDow)
+			if err != nil {
+				return &CronError{Expr: expr, Field: fieldDow.name, Value: part, Msg: err.Error()}
+			}
+			c.dowL = append(c.dowL, d%7)
+		case strings.Contains(p, "#"):
+			sp := strings.SplitN(p, "#", 2)
+			d, err := parseCronValue(sp[0], fieldDow)
+			if err != nil {
+				return &CronError{Expr: expr, Field: fieldDow.name, Value: part, Msg: err.Error()}
+			}
+			k, err := strconv.Atoi(sp[1])
+			if err != nil || k < 1 || k > 5 {
+				return &CronError{Expr: expr, Field: fieldDow.name, Value: part, Msg: "n#k occurrence must be in [1,5]"}
+			}
+			c.dowNth = append(c.dowNth, [2]int{d % 7, k})
+		default:
+			plain = append(plain, part)
+		}
+	}
+	if len(plain) == 0 {
+		return nil
+	}
+	bits, star, err := parseCronField(expr, strings.Join(plain, ","), fieldDow)
+	if err != nil {
+		return err
+	}
+	if bits&(1<<7) != 0 {
+		bits = bits&^(1<<7) | 1
+	}
+	c.dow, c.dowStar = bits, star
+	if star && (len(c.dowL) > 0 || len(c.dowNth) > 0) {
+		return &CronError{Expr: expr, Field: fieldDow.name, Value: field, Msg: "* cannot be combined with L or #"}
+	}
+	return nil
+}
+
+// parseCronField 解析普通字段为位图，star 表示不受限（* 或 ?）
+func parseCronField(expr, field string, f cronField) (uint64, bool, error) {
+	var res uint64
+	star := false
+	for _, part := range strings.Split(field, ",") {
+		b, s, err := parseCronPart(part, f)
+		if err != nil {
+			return 0, false, &CronError{Expr: expr, Field: f.name, Value: part, Msg: err.Error()}
+		}
+		res |= b
+		star = star || s
+	}
+	return res, star, nil
+}
+
+func parseCronPart(part string, f cronField) (uint64, bool, error) {
+	if part == "" {
+		return 0, false, fmt.Errorf("empty value")
+	}
+	rng, stepStr, hasStep := strings.Cut(part, "/")
+	step := 1
+	if hasStep {
+		n, err := strconv.Atoi(stepStr)
+		if err != nil || n <= 0 {
+			return 0, false, fmt.Errorf("invalid step %q", stepStr)
+		}
+		if n > f.max-f.min+1 {
+			return 0, false, fmt.Errorf("step %d exceeds range [%d,%d]", n, f.min, f.max)
+		}
+		step = n
+	}
+	var lo, hi int
+	star := false
+	switch {
+	case rng == "*" || rng == "?":
+		if rng == "?" && f.name != fieldDom.name && f.name != fieldDow.name {
+			return 0, false, fmt.Errorf("? is only allowed in day-of-month and day-of-week")
+		}
+		lo, hi = f.min, f.max
+		if f.name == fieldDow.name {
+			hi = 6
+		}
+		star = !hasStep
+	case strings.Contains(rng, "-"):
+		a, b, _ := strings.Cut(rng, "-")
+		var err error
+		if lo, err = parseCronValue(a, f); err != nil {
+			return 0, false, err
+		}
+		if hi, err = parseCronValue(b, f); err != nil {
+			return 0, false, err
+		}
+		if lo > hi {
+			return 0, false, fmt.Errorf("range start %d is greater than end %d", lo, hi)
+		}
+	default:
+		var err error
+		if lo, err = parseCronValue(rng, f); err != nil {
+			return 0, false, err
+		}
+		hi = lo
+		if hasStep {
+			// a/n 与 * 一样止于 6，否则周日会以 7 的身份混入步进结果
+			hi = f.max
+			if f.name == fieldDow.name {
+				hi = 6
+			}
+		}
+	}
+	var b uint64
+	for i := lo; i <= hi; i += step {
+		b |= 1 << uint(i)
+	}
+	return b, star, nil
+}
+
+// parseCronValue 解析单个数值或名称并校验范围
+func parseCronValue(s string, f cronField) (int, error) {
+	if f.names != nil {
+		if n, ok := f.names[strings.ToUpper(s)]; ok {
+			return n, nil
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	if n < f.min || n > f.max {
+		return 0, fmt.Errorf("value %d out of range [%d,%d]", n, f.min, f.max)
+	}
+	return n, nil
+}
+
+// String 返回原始表达式
+func (c *Cron) String() string { return c.expr }
+
+// Location 返回表达式指定的时区，未指定时为 nil
+func (c *Cron) Location() *time.Location { return c.loc }
+
+// Next 返回 t 之后的下一次触发时间，找不到时返回零值
+func (c *Cron) Next(t time.Time) time.Time {
+	if c.every > 0 {
+		return t.Add(c.every)
+	}
+	loc := c.location(t)
+	w := wallOf(t.In(loc)).Add(time.Second)
+	limit := w.Year() + maxCronYears
+	for {
+		m, ok := c.nextWall(w, limit)
+		if !ok {
+			return time.Time{}
+		}
+		if r := wallToTime(m, loc); r.After(t) {
+			return r
+		}
+		w = m.Add(time.Second)
+	}
+}
+
+// Prev 返回 t 之前的上一次触发时间，找不到时返回零值
+func (c *Cron) Prev(t time.Time) time.Time {
+	if c.every > 0 {
+		return t.Add(-c.every)
+	}
+	loc := c.location(t)
+	lt := t.In(loc)
+	w := wallOf(lt)
+	if lt.Nanosecond() == 0 {
+		w = w.Add(-time.Second)
+	}
+	limit := w.Year() - maxCronYears
+	for {
+		m, ok := c.prevWall(w, limit)
+		if !ok {
+			return time.Time{}
+		}
+		r := wallToTime(m, loc)
+		// 跳过区间内有多个匹配时 Next 只在最早的一个触发，Prev 也取它
+		for !wallOf(r).Equal(m) {
+			m2, ok := c.prevWall(m.Add(-time.Second), limit)
+			if !ok || m.Sub(m2) >= 6*time.Hour {
+				break
+			}
+			r2 := wallToTime(m2, loc)
+			if wallOf(r2).Equal(m2) {
+				break
+			}
+			m, r = m2, r2
+		}
+		if r.Before(t) {
+			return r
+		}
+		w = m.Add(-time.Second)
+	}
+}
+
+// NextN 返回 t 之后的 n 次触发时间，用于预览
+func (c *Cron) NextN(t time.Time, n int) []time.Time {
+	res := make([]time.Time, 0, n)
+	for range n {
+		t = c.Next(t)
+		if t.IsZero() {
+			break
+		}
+		res = append(res, t)
+	}
+	return res
+}
+
+func (c *Cron) location(t time.Time) *time.Location {
+	if c.loc != nil {
+		return c.loc
+	}
+	return t.Location()
+}
+
+// nextWall 在墙上时间（以 UTC 表示）上逐字段跳跃，返回不早于 w 的首个匹配
+func (c *Cron) nextWall(w time.Time, limit int) (time.Time, bool) {
+	for w.Year() <= limit {
+		y, mo, d := w.Date()
+		if !has(c.mon, int(mo)) {
+			if m := nextBit(c.mon, int(mo)+1); m > 0 {
+				w = wallDate(y, time.Month(m), 1, 0, 0, 0)
+			} else {
+				w = wallDate(y+1, 1, 1, 0, 0, 0)
+			}
+			continue
+		}
+		if !c.matchDay(w) {
+			w = wallDate(y, mo, d+1, 0, 0, 0)
+			continue
+		}
+		h := nextBit(c.hour, w.Hour())
+		if h < 0 {
+			w = wallDate(y, mo, d+1, 0, 0, 0)
+			continue
+		}
+		if h != w.Hour() {
+			w = wallDate(y, mo, d, h, 0, 0)
+		}
+		mi := nextBit(c.min, w.Minute())
+		if mi < 0 {
+			w = wallDate(y, mo, d, h+1, 0, 0)
+			continue
+		}
+		if mi != w.Minute() {
+			w = wallDate(y, mo, d, h, mi, 0)
+		}
+		sec := nextBit(c.sec, w.Second())
+		if sec < 0 {
+			w = wallDate(y, mo, d, h, mi+1, 0)
+			continue
+		}
+		return wallDate(y, mo, d, h, mi, sec), true
+	}
+	return time.Time{}, false
+}
+
+// prevWall 与 nextWall 对称，返回不晚于 w 的最后一个匹配
+func (c *Cron) prevWall(w time.Time, limit int) (time.Time, bool) {
+	for w.Year() >= limit {
+		y, mo, d := w.Date()
+		if !has(c.mon, int(mo)) {
+			if m := prevBit(c.mon, int(mo)-1); m > 0 {
+				w = wallDate(y, time.Month(m)+1, 1, 0, 0, -1)
+			} else {
+				w = wallDate(y, 1, 1, 0, 0, -1)
+			}
+			continue
+		}
+		if !c.matchDay(w) {
+			w = wallDate(y, mo, d, 0, 0, -1)
+			continue
+		}
+		h := prevBit(c.hour, w.Hour())
+		if h < 0 {
+			w = wallDate(y, mo, d, 0, 0, -1)
+			continue
+		}
+		if h != w.Hour() {
+			w = wallDate(y, mo, d, h, 59, 59)
+		}
+		mi := prevBit(c.min, w.Minute())
+		if mi < 0 {
+			w = wallDate(y, mo, d, h, 0, -1)
+			continue
+		}
+		if mi != w.Minute() {
+			w = wallDate(y, mo, d, h, mi, 59)
+		}
+		sec := prevBit(c.sec, w.Second())
+		if sec < 0 {
+			w = wallDate(y, mo, d, h, mi, -1)
+			continue
+		}
+		return wallDate(y, mo, d, h, mi, sec), true
+	}
+	return time.Time{}, false
+}
+
+func wallDate(y int, mo time.Month, d, h, mi, sec int) time.Time {
+	return time.Date(y, mo, d, h, mi, sec, 0, time.UTC)
+}
+
+// wallOf 将 t 的墙上时间（截断到秒）表示为 UTC
+func wallOf(t time.Time) time.Time {
+	return wallDate(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second())
+}
+
+// wallToTime 将墙上时间 w 解释为 loc 中的时刻
+// 跳过区间内的时刻顺延跳变的时长，重复出现的时刻取较早的一次
+func wallToTime(w time.Time, loc *time.Location) time.Time {
+	c := time.Date(w.Year(), w.Month(), w.Day(), w.Hour(), w.Minute(), w.Second(), 0, loc)
+	// 夏令时切换相隔数月，前后 6 小时内至多一次
+	_, before := c.Add(-6 * time.Hour).Zone()
+	_, after := c.Add(6 * time.Hour).Zone()
+	if before == after {
+		return c
+	}
+	u1 := w.Add(-time.Duration(before) * time.Second).In(loc)
+	u2 := w.Add(-time.Duration(after) * time.Second).In(loc)
+	ok1, ok2 := wallOf(u1).Equal(w), wallOf(u2).Equal(w)
+	switch {
+	case ok1 && ok2:
+		if u2.Before(u1) {
+			return u2
+		}
+		return u1
+	case ok1:
+		return u1
+	case ok2:
+		return u2
+	}
+	if u2.After(u1) {
+		return u2
+	}
+	return u1
+}
+
+// matchDay 日与周都受限时取并集，否则取交集（不受限的一方恒为真）
+func (c *Cron) matchDay(t time.Time) bool {
+	domOK := c.domMatch(t)
+	dowOK := c.dowMatch(t)
+	if c.domStar || c.dowStar {
+		return domOK && dowOK
+	}
+	return domOK || dowOK
+}
+
+func (c *Cron) domMatch(t time.Time) bool {
+	if c.domStar || has(c.dom, t.Day()) {
+		return true
+	}
+	last := daysIn(t.Year(), t.Month())
+	for _, n := range c.domL {
+		if t.Day() == last-n {
+			return true
+		}
+	}
+	for _, n := range c.domW {
+		if t.Day() == nearestWeekday(t.Year(), t.Month(), n) {
+			return true
+		}
+	}
+	return c.domLW && t.Day() == nearestWeekday(t.Year(), t.Month(), last)
+}
+
+func (c *Cron) dowMatch(t time.Time) bool {
+	wd := int(t.Weekday())
+	if c.dowStar || has(c.dow, wd) {
+		return true
+	}
+	last := daysIn(t.Year(), t.Month())
+	for _, d := range c.dowL {
+		if wd == d && t.Day()+7 > last {
+			return true
+		}
+	}
+	for _, nk := range c.dowNth {
+		if wd == nk[0] && (t.Day()-1)/7+1 == nk[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// daysIn 当月天数
+func daysIn(y int, m time.Month) int {
+	return time.Date(y, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// nearestWeekday 离 day 号最近的工作日，不跨月（Quartz W 语义）
+func nearestWeekday(y int, m time.Month, day int) int {
+	last := daysIn(y, m)
+	if day > last {
+		day = last
+	}
+	switch time.Date(y, m, day, 0, 0, 0, 0, time.UTC).Weekday() {
+	case time.Saturday:
+		if day == 1 {
+			return 3
+		}
+		return day - 1
+	case time.Sunday:
+		if day == last {
+			return day - 2
+		}
+		return day + 1
+	}
+	return day
+}
+
+func has(b uint64, v int) bool { return b&(1<<uint(v)) != 0 }
+
+// prevBit 返回 <= from 的最大置位下标，不存在返回 -1
+func prevBit(b uint64, from int) int {
+	if from < 0 {
+		return -1
+	}
+	if from < 63 {
+		b &= (1 << uint(from+1)) - 1
+	}
+	if b == 0 {
+		return -1
+	}
+	return 63 - bits.LeadingZeros64(b)
+}
+
+// nextBit 返回 >= from 的最小置位下标，不存在返回 -1
+func nextBit(b uint64, from int) int {
+	if from >= 64 {
+		return -1
+	}
+	b &^= (1 << uint(from)) - 1
+	if b == 0 {
+		return -1
+	}
+	return bits.TrailingZeros64(b)
+}
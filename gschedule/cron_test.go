@@ -0,0 +1,298 @@
+package gschedule
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func set(vals ...int) uint64 {
+	var b uint64
+	for _, v := range vals {
+		b |= 1 << uint(v)
+	}
+	return b
+}
+
+func span(lo, hi int) uint64 {
+	var b uint64
+	for i := lo; i <= hi; i++ {
+		b |= 1 << uint(i)
+	}
+	return b
+}
+
+func mustCron(t *testing.T, expr string, opt ...CronOptions) *Cron {
+	t.Helper()
+	c, err := NewCron(expr, opt...)
+	if err != nil {
+		t.Fatalf("NewCron(%q): %v", expr, err)
+	}
+	return c
+}
+
+func TestParseCronFields(t *testing.T) {
+	for _, c := range []struct {
+		expr                          string
+		sec, min, hour, dom, mon, dow uint64
+		domStar, dowStar              bool
+	}{
+		{"* * * * *", set(0), span(0, 59), span(0, 23), span(1, 31), span(1, 12), span(0, 6), true, true},
+		{"0 30 9 * * MON-FRI", set(0), set(30), set(9), span(1, 31), span(1, 12), span(1, 5), true, false},
+		{"*/15 * * * *", set(0), set(0, 15, 30, 45), span(0, 23), span(1, 31), span(1, 12), span(0, 6), true, true},
+		{"5/20 */6 * * *", set(0), set(5, 25, 45), set(0, 6, 12, 18), span(1, 31), span(1, 12), span(0, 6), true, true},
+		{"10-30/10 1,2,5-7 * * *", set(0), set(10, 20, 30), set(1, 2, 5, 6, 7), span(1, 31), span(1, 12), span(0, 6), true, true},
+		{"0 0 1/10 jan-Mar *", set(0), set(0), set(0), set(1, 11, 21, 31), set(1, 2, 3), span(0, 6), false, true},
+		{"0 0 ? * mon", set(0), set(0), set(0), span(1, 31), span(1, 12), set(1), true, false},
+		// a/n 与 */n 对星期都止于 6，7 只在显式写出时表示周日
+		{"0 0 * * 1/2", set(0), set(0), set(0), span(1, 31), span(1, 12), set(1, 3, 5), true, false},
+		{"0 0 * * 0/3", set(0), set(0), set(0), span(1, 31), span(1, 12), set(0, 3, 6), true, false},
+		{"0 0 * * */2", set(0), set(0), set(0), span(1, 31), span(1, 12), set(0, 2, 4, 6), true, false},
+		{"0 0 * * 7", set(0), set(0), set(0), span(1, 31), span(1, 12), set(0), true, false},
+		{"0 0 * * 5-7", set(0), set(0), set(0), span(1, 31), span(1, 12), set(0, 5, 6), true, false},
+		{"0 0 * * SUN,sat", set(0), set(0), set(0), span(1, 31), span(1, 12), set(0, 6), true, false},
+		{"*/20 0 12 * * *", set(0, 20, 40), set(0), set(12), span(1, 31), span(1, 12), span(0, 6), true, true},
+	} {
+		cr := mustCron(t, c.expr)
+		got := [6]uint64{cr.sec, cr.min, cr.hour, cr.dom, cr.mon, cr.dow}
+		want := [6]uint64{c.sec, c.min, c.hour, c.dom, c.mon, c.dow}
+		if got != want || cr.domStar != c.domStar || cr.dowStar != c.dowStar {
+			t.Errorf("%q: got %b star=%v/%v", c.expr, got, cr.domStar, cr.dowStar)
+		}
+	}
+}
+
+func TestParseCronSpecialDays(t *testing.T) {
+	for _, c := range []struct {
+		expr   string
+		domL   []int
+		domW   []int
+		domLW  bool
+		dowL   []int
+		dowNth [][2]int
+	}{
+		{expr: "0 0 L * *", domL: []int{0}},
+		{expr: "0 0 L-3,l * *", domL: []int{3, 0}},
+		{expr: "0 0 15W,1w * *", domW: []int{15, 1}},
+		{expr: "0 0 LW * *", domLW: true},
+		{expr: "0 0 ? * 5L", dowL: []int{5}},
+		{expr: "0 0 ? * L,friL,7L", dowL: []int{6, 5, 0}},
+		{expr: "0 0 ? * 5#3,SUN#1,7#2", dowNth: [][2]int{{5, 3}, {0, 1}, {0, 2}}},
+	} {
+		cr := mustCron(t, c.expr)
+		if !reflect.DeepEqual(cr.domL, c.domL) || !reflect.DeepEqual(cr.domW, c.domW) || cr.domLW != c.domLW ||
+			!reflect.DeepEqual(cr.dowL, c.dowL) || !reflect.DeepEqual(cr.dowNth, c.dowNth) {
+			t.Errorf("%q: L=%v W=%v LW=%v dowL=%v nth=%v", c.expr, cr.domL, cr.domW, cr.domLW, cr.dowL, cr.dowNth)
+		}
+	}
+}
+
+func TestParseCronMacros(t *testing.T) {
+	for macro, expr := range map[string]string{
+		"@yearly":   "0 0 0 1 1 *",
+		"@Annually": "0 0 0 1 1 *",
+		"@monthly":  "0 0 0 1 * *",
+		"@weekly":   "0 0 0 * * 0",
+		"@daily":    "0 0 0 * * *",
+		"@midnight": "0 0 0 * * *",
+		"@HOURLY":   "0 0 * * * *",
+	} {
+		got, want := mustCron(t, macro), mustCron(t, expr)
+		got.expr, want.expr = "", ""
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s differs from %q", macro, expr)
+		}
+	}
+	c := mustCron(t, "@every 90s")
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if c.every != 90*time.Second || !c.Next(at).Equal(at.Add(90*time.Second)) || !c.Prev(at).Equal(at.Add(-90*time.Second)) {
+		t.Errorf("@every 90s: every=%v", c.every)
+	}
+	if mustCron(t, "@EVERY 1h30m").every != 90*time.Minute {
+		t.Error("@every is case sensitive")
+	}
+}
+
+func TestParseCronTimeZone(t *testing.T) {
+	for _, expr := range []string{"CRON_TZ=Asia/Shanghai 0 9 * * *", "TZ=Asia/Shanghai 0 9 * * *", "  CRON_TZ=Asia/Shanghai   @daily"} {
+		c := mustCron(t, expr)
+		if c.Location() == nil || c.Location().String() != "Asia/Shanghai" {
+			t.Errorf("%q: location %v", expr, c.Location())
+		}
+		if c.String() != expr {
+			t.Errorf("String() = %q, want %q", c.String(), expr)
+		}
+	}
+	// 表达式中的时区优先于选项
+	ny, _ := time.LoadLocation("America/New_York")
+	if c := mustCron(t, "CRON_TZ=UTC 0 9 * * *", CronOptions{Location: ny}); c.Location() != time.UTC {
+		t.Errorf("CRON_TZ did not override option: %v", c.Location())
+	}
+	if c := mustCron(t, "0 9 * * *", CronOptions{Location: ny}); c.Location() != ny {
+		t.Errorf("option location ignored: %v", c.Location())
+	}
+	if c := mustCron(t, "0 9 * * *"); c.Location() != nil {
+		t.Errorf("unexpected location %v", c.Location())
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, c := range []struct {
+		expr, field, msg string
+	}{
+		{"", "", "need 5 or 6 fields, got 0"},
+		{"* * * *", "", "need 5 or 6 fields, got 4"},
+		{"* * * * * * *", "", "need 5 or 6 fields, got 7"},
+		{"60 * * * *", "minute", `field minute "60": value 60 out of range [0,59]`},
+		{"* 24 * * *", "hour", "value 24 out of range [0,23]"},
+		{"* * 0 * *", "day-of-month", "value 0 out of range [1,31]"},
+		{"* * * 13 *", "month", "value 13 out of range [1,12]"},
+		{"* * * * 8", "day-of-week", "value 8 out of range [0,7]"},
+		{"60 * * * * *", "second", "value 60 out of range [0,59]"},
+		{"*/0 * * * *", "minute", `invalid step "0"`},
+		{"*/x * * * *", "minute", `invalid step "x"`},
+		{"*/61 * * * *", "minute", "step 61 exceeds range [0,59]"},
+		{"5-1 * * * *", "minute", "range start 5 is greater than end 1"},
+		{"a * * * *", "minute", `invalid value "a"`},
+		{"* * * FOO *", "month", `invalid value "FOO"`},
+		{"1,,2 * * * *", "minute", "empty value"},
+		{"? * * * *", "minute", "? is only allowed in day-of-month and day-of-week"},
+		{"* * L-31 * *", "day-of-month", "L-n offset must be in [1,30]"},
+		{"* * 32W * *", "day-of-month", "nW day must be in [1,31]"},
+		{"* * *,L * *", "day-of-month", "* cannot be combined with L or W"},
+		{"* * * * 5#6", "day-of-week", "n#k occurrence must be in [1,5]"},
+		{"* * * * 9#1", "day-of-week", "value 9 out of range [0,7]"},
+		{"* * * * 8L", "day-of-week", "value 8 out of range [0,7]"},
+		{"* * * * *,5L", "day-of-week", "* cannot be combined with L or #"},
+		{"@every", "", "@every needs a duration"},
+		{"@every -1s", "@every", "invalid duration"},
+		{"@every soon", "@every", "invalid duration"},
+		{"@sometimes", "", "unknown macro @sometimes"},
+		{"@daily 5", "", "unknown macro @daily 5"},
+		{"CRON_TZ=Mars/Base 0 9 * * *", "CRON_TZ", `field CRON_TZ "Mars/Base"`},
+	} {
+		_, err := NewCron(c.expr)
+		var ce *CronError
+		if !errors.As(err, &ce) {
+			t.Errorf("%q: error %v is not a *CronError", c.expr, err)
+			continue
+		}
+		if ce.Field != c.field || ce.Expr != c.expr {
+			t.Errorf("%q: Field=%q Expr=%q", c.expr, ce.Field, ce.Expr)
+		}
+		if !strings.Contains(err.Error(), c.msg) || !strings.HasPrefix(err.Error(), "gschedule: cron ") {
+			t.Errorf("%q: error %q does not mention %q", c.expr, err, c.msg)
+		}
+	}
+}
+
+// 星期步进不应把周日当作 7 选中
+func TestCronDowStepSkipsSunday(t *testing.T) {
+	c := mustCron(t, "0 0 * * 1/2")
+	got := c.NextN(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), 4)
+	want := []time.Time{
+		time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), // 周三
+		time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), // 周五
+		time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC), // 周一，跳过 1 月 7 日周日
+		time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("NextN = %v", got)
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	utc := func(y int, mo time.Month, d, h, mi int) time.Time { return time.Date(y, mo, d, h, mi, 0, 0, time.UTC) }
+	at := func(y int, mo time.Month, d, h, mi int) time.Time { return time.Date(y, mo, d, h, mi, 0, 0, ny) }
+	for _, c := range []struct {
+		name string
+		expr string
+		from time.Time
+		want []time.Time
+	}{
+		{"every minute", "* * * * *", utc(2024, 1, 1, 0, 0), []time.Time{utc(2024, 1, 1, 0, 1), utc(2024, 1, 1, 0, 2)}},
+		{"seconds", "*/20 * * * * *", time.Date(2024, 1, 1, 0, 0, 5, 0, time.UTC),
+			[]time.Time{time.Date(2024, 1, 1, 0, 0, 20, 0, time.UTC), time.Date(2024, 1, 1, 0, 0, 40, 0, time.UTC), utc(2024, 1, 1, 0, 1)}},
+		{"sub-second start", "0 * * * * *", time.Date(2024, 1, 1, 0, 0, 59, 999, time.UTC), []time.Time{utc(2024, 1, 1, 0, 1)}},
+		{"year rollover", "0 0 1 1 *", utc(2024, 6, 1, 0, 0), []time.Time{utc(2025, 1, 1, 0, 0), utc(2026, 1, 1, 0, 0)}},
+		// 月末与闰年
+		{"31st only", "0 0 31 * *", utc(2024, 1, 31, 0, 0), []time.Time{utc(2024, 3, 31, 0, 0), utc(2024, 5, 31, 0, 0), utc(2024, 7, 31, 0, 0), utc(2024, 8, 31, 0, 0)}},
+		{"leap day", "0 0 29 2 *", utc(2024, 3, 1, 0, 0), []time.Time{utc(2028, 2, 29, 0, 0), utc(2032, 2, 29, 0, 0)}},
+		{"last day", "0 0 L * *", utc(2024, 1, 15, 0, 0), []time.Time{utc(2024, 1, 31, 0, 0), utc(2024, 2, 29, 0, 0), utc(2024, 3, 31, 0, 0), utc(2024, 4, 30, 0, 0)}},
+		{"last day of february", "0 0 L 2 *", utc(2023, 1, 1, 0, 0), []time.Time{utc(2023, 2, 28, 0, 0), utc(2024, 2, 29, 0, 0), utc(2025, 2, 28, 0, 0)}},
+		{"L-2", "0 0 L-2 * *", utc(2024, 2, 1, 0, 0), []time.Time{utc(2024, 2, 27, 0, 0), utc(2024, 3, 29, 0, 0)}},
+		{"LW", "0 0 LW * *", utc(2024, 3, 1, 0, 0), []time.Time{utc(2024, 3, 29, 0, 0), utc(2024, 4, 30, 0, 0), utc(2024, 5, 31, 0, 0), utc(2024, 6, 28, 0, 0)}},
+		{"15W on saturday", "0 0 15W 6 *", utc(2024, 1, 1, 0, 0), []time.Time{utc(2024, 6, 14, 0, 0)}},
+		{"1W stays in month", "0 0 1W 6 *", utc(2024, 1, 1, 0, 0), []time.Time{utc(2024, 6, 3, 0, 0)}},
+		{"third friday", "0 0 ? * 5#3", utc(2024, 1, 1, 0, 0), []time.Time{utc(2024, 1, 19, 0, 0), utc(2024, 2, 16, 0, 0)}},
+		{"last monday", "0 0 ? * 1L", utc(2024, 1, 1, 0, 0), []time.Time{utc(2024, 1, 29, 0, 0), utc(2024, 2, 26, 0, 0)}},
+		{"fifth monday of february", "0 0 ? 2 1#5", utc(2024, 3, 1, 0, 0), []time.Time{utc(2044, 2, 29, 0, 0)}},
+		{"dom or dow", "0 0 13 * 5", utc(2024, 9, 1, 0, 0), []time.Time{utc(2024, 9, 6, 0, 0), utc(2024, 9, 13, 0, 0), utc(2024, 9, 20, 0, 0)}},
+		{"never", "0 0 30 2 *", utc(2024, 1, 1, 0, 0), []time.Time{}},
+		// 2024-03-10 02:00 EST 跳到 03:00 EDT
+		{"spring forward", "30 2 * * *", at(2024, 3, 9, 12, 0), []time.Time{at(2024, 3, 10, 3, 30), at(2024, 3, 11, 2, 30)}},
+		{"spring forward once", "*/30 2 * * *", at(2024, 3, 10, 0, 0), []time.Time{at(2024, 3, 10, 3, 0), at(2024, 3, 11, 2, 0)}},
+		{"spring forward overlap", "0 2,3 * * *", at(2024, 3, 10, 0, 0), []time.Time{at(2024, 3, 10, 3, 0), at(2024, 3, 11, 2, 0)}},
+		{"spring forward minutes", "* * * * *", at(2024, 3, 10, 1, 58), []time.Time{at(2024, 3, 10, 1, 59), at(2024, 3, 10, 3, 0), at(2024, 3, 10, 3, 1)}},
+		// 2024-11-03 02:00 EDT 回拨到 01:00 EST，重复的时刻只在较早的一次触发
+		{"fall back", "30 1 * * *", at(2024, 11, 2, 12, 0), []time.Time{utc(2024, 11, 3, 5, 30), utc(2024, 11, 4, 6, 30)}},
+		{"fall back hourly", "0 * * * *", at(2024, 11, 3, 0, 30), []time.Time{utc(2024, 11, 3, 5, 0), utc(2024, 11, 3, 7, 0), utc(2024, 11, 3, 8, 0)}},
+		{"fall back from second occurrence", "30 1 * * *", utc(2024, 11, 3, 6, 0).In(ny), []time.Time{utc(2024, 11, 4, 6, 30)}},
+		// CRON_TZ 优先于传入时间的时区，结果落在表达式的时区
+		{"CRON_TZ", "CRON_TZ=Asia/Shanghai 0 9 * * *", utc(2024, 1, 1, 0, 0), []time.Time{utc(2024, 1, 1, 1, 0), utc(2024, 1, 2, 1, 0)}},
+		{"CRON_TZ spring forward", "CRON_TZ=America/New_York 30 2 * * *", utc(2024, 3, 9, 17, 0), []time.Time{utc(2024, 3, 10, 7, 30), utc(2024, 3, 11, 6, 30)}},
+		{"own location", "0 9 * * *", at(2024, 1, 1, 10, 0), []time.Time{at(2024, 1, 2, 9, 0)}},
+	} {
+		cr := mustCron(t, c.expr)
+		got := cr.NextN(c.from, max(len(c.want), 1))
+		if len(got) != len(c.want) {
+			t.Errorf("%s: NextN(%v) = %v, want %v", c.name, c.from, got, c.want)
+			continue
+		}
+		for i := range c.want {
+			if !got[i].Equal(c.want[i]) {
+				t.Errorf("%s: NextN(%v) = %v, want %v", c.name, c.from, got, c.want)
+				break
+			}
+		}
+		if cr.Location() != nil && len(got) > 0 && got[0].Location() != cr.Location() {
+			t.Errorf("%s: result in %v, want %v", c.name, got[0].Location(), cr.Location())
+		}
+		// Prev 与 Next 互逆
+		for i := 1; i < len(got); i++ {
+			if p := cr.Prev(got[i]); !p.Equal(got[i-1]) {
+				t.Errorf("%s: Prev(%v) = %v, want %v", c.name, got[i], p, got[i-1])
+			}
+		}
+		if len(got) > 0 {
+			if p := cr.Prev(got[0]); p.After(c.from) {
+				t.Errorf("%s: Prev(%v) = %v is after start %v", c.name, got[0], p, c.from)
+			}
+		}
+	}
+}
+
+func TestCronPrev(t *testing.T) {
+	utc := func(y int, mo time.Month, d, h, mi int) time.Time { return time.Date(y, mo, d, h, mi, 0, 0, time.UTC) }
+	for _, c := range []struct {
+		expr       string
+		from, want time.Time
+	}{
+		{"0 0 29 2 *", utc(2028, 1, 1, 0, 0), utc(2024, 2, 29, 0, 0)},
+		{"0 0 L * *", utc(2024, 3, 1, 0, 0), utc(2024, 2, 29, 0, 0)},
+		{"0 0 1 1 *", utc(2024, 1, 1, 0, 0), utc(2023, 1, 1, 0, 0)},
+		{"0 0 1 1 *", time.Date(2024, 1, 1, 0, 0, 0, 1, time.UTC), utc(2024, 1, 1, 0, 0)},
+		{"0 0 30 2 *", utc(2024, 1, 1, 0, 0), time.Time{}},
+	} {
+		if got := mustCron(t, c.expr).Prev(c.from); !got.Equal(c.want) {
+			t.Errorf("%q: Prev(%v) = %v, want %v", c.expr, c.from, got, c.want)
+		}
+	}
+}
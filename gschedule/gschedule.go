@@ -2,9 +2,10 @@ package gschedule
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"runtime/debug"
 	"sort"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -20,13 +21,102 @@ type JobFunc func()
 
 func (f JobFunc) Run() { f() }
 
+// ErrorJob 返回错误的任务
+type ErrorJob interface {
+	Run() error
+}
+
+// ErrorJobFunc 函数式 ErrorJob
+type ErrorJobFunc func() error
+
+func (f ErrorJobFunc) Run() error { return f() }
+
+// ContextJob 感知 ctx 的任务，ctx 在任务被移除、调度器停止（StopContext 为到达期限时）或超时时取消
+type ContextJob interface {
+	Run(ctx context.Context) error
+}
+
+// ContextJobFunc 函数式 ContextJob
+type ContextJobFunc func(ctx context.Context) error
+
+func (f ContextJobFunc) Run(ctx context.Context) error { return f(ctx) }
+
+// PanicError 任务 panic 时转换得到的错误
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("gschedule: job panic: %v", e.Value)
+}
+
+// Recover 包装 job，将 panic 转为 *PanicError 返回
+func Recover(job Job) ErrorJob {
+	fn := recovered(func(context.Context) error {
+		job.Run()
+		return nil
+	})
+	return ErrorJobFunc(func() error { return fn(context.Background()) })
+}
+
+func recovered(fn ContextJobFunc) ContextJobFunc {
+	return func(ctx context.Context) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = &PanicError{Value: r, Stack: debug.Stack()}
+			}
+		}()
+		return fn(ctx)
+	}
+}
+
+// Options 调度器选项
+type Options struct {
+	// 每次执行前回调
+	BeforeRun func(id string)
+	// 每次执行后回调（无论成功与否）
+	AfterRun func(id string, rec RunRecord)
+	// 执行返回错误或 panic 时回调
+	OnError func(id string, err error)
+	// 每个任务保留的执行历史条数，默认 DefaultHistorySize
+	HistorySize int
+	// 持久化存储，AddStored 的任务及其最近执行时间写入其中
+	Store JobStore
+	// Restore 时对错过的触发的补跑策略
+	CatchUp CatchUp
+	// CatchUpAll 下每个任务的补跑上限，默认 DefaultMaxCatchUp
+	MaxCatchUp int
+	// 分布式锁，多实例部署时每次触发只有获得租约的实例执行
+	Locker Locker
+	// 租约时长，默认 DefaultLockTTL，应大于各实例间的时钟偏差
+	LockTTL time.Duration
+}
+
+// DefaultHistorySize 默认保留的执行历史条数
+const DefaultHistorySize = 16
+
+// ErrTaskNotFound 任务不存在
+var ErrTaskNotFound = errors.New("gschedule: task not found")
+
 // Task 封装任务
 type Task struct {
 	ID       string
-	Schedule Schedule
+	Schedule Schedule // 通过 UpdateSchedule 修改
 	Job      Job
-	ctx      context.Context
+	ctx      context.Context // 调度循环
+	jobCtx   context.Context // 传给任务，StopContext 时延后到期限才取消
 	cancel   context.CancelFunc
+	fn       ContextJobFunc // 已包装 panic 恢复
+	opt      TaskOptions
+	running  chan struct{} // SkipIfRunning、DelayIfRunning 下的执行令牌
+	status   taskStatus
+	record   *JobRecord // 持久化任务的记录，非持久化任务为 nil
+	recordMu sync.Mutex
+	mu       sync.Mutex    // 保护 Schedule、paused、idle
+	paused   bool          // 暂停期间到点的触发被跳过
+	idle     bool          // 调度已结束（Next 返回零值），runTask 已退出
+	wake     chan struct{} // 通知 runTask 重新计算下一次触发时间
 }
 
 // Schedule 接口：返回下一次执行时间点
@@ -49,110 +139,101 @@ func (d Delay) Next(t time.Time) time.Time {
 	return time.Time{} // 只执行一次
 }
 
-// Cron 5 位标准：min hour day month week
-type Cron struct {
-	expr string
-	min  []int
-	hour []int
-	dom  []int
-	mon  []int
-	dow  []int
-}
-
-// NewCron 解析 5 位 cron
-// 例："* 2-4 * * 1-5"
-func NewCron(expr string) (*Cron, error) {
-	fields := strings.Fields(expr)
-	if len(fields) != 5 {
-		return nil, fmt.Errorf("cron need 5 fields")
-	}
-	c := &Cron{expr: expr}
-	var err error
-	c.min, err = parseField(fields[0], 0, 59)
-	if err != nil {
-		return nil, err
-	}
-	c.hour, err = parseField(fields[1], 0, 23)
-	if err != nil {
-		return nil, err
-	}
-	c.dom, err = parseField(fields[2], 1, 31)
-	if err != nil {
-		return nil, err
-	}
-	c.mon, err = parseField(fields[3], 1, 12)
-	if err != nil {
-		return nil, err
-	}
-	c.dow, err = parseField(fields[4], 0, 6)
-	if err != nil {
-		return nil, err
-	}
-	return c, nil
-}
-
-func (c *Cron) Next(t time.Time) time.Time {
-	// 简单实现：逐分钟加，直到匹配
-	t = t.Truncate(time.Minute).Add(time.Minute)
-	for !t.After(time.Now().Add(366 * 24 * time.Hour)) {
-		if c.match(t) {
-			return t
-		}
-		t = t.Add(time.Minute)
-	}
-	return time.Time{}
-}
-
-func (c *Cron) match(t time.Time) bool {
-	return contains(c.min, t.Minute()) &&
-		contains(c.hour, t.Hour()) &&
-		contains(c.dom, t.Day()) &&
-		contains(c.mon, int(t.Month())) &&
-		contains(c.dow, int(t.Weekday()))
-}
-
 // Scheduler 调度器
 type Scheduler struct {
 	taskMu sync.RWMutex
 	tasks  map[string]*Task
-	pool   chan struct{} // 协程池令牌
-	wg     sync.WaitGroup
+	pool   chan struct{}  // 协程池令牌
+	wg     sync.WaitGroup // runTask 与 TriggerNow 协程
 	ctx    context.Context
 	cancel context.CancelFunc
+	opt    Options
+	closed bool // 已 Stop，受 taskMu 保护
+
+	jobs      sync.WaitGroup // 执行中的任务实例
+	jobCtx    context.Context
+	jobCancel context.CancelFunc
+	jobMu     sync.Mutex
+	inflight  map[string]int // 各任务执行中的实例数
 }
 
 // New 创建调度器，poolSize 控制最大并发
-func New(poolSize int) *Scheduler {
+func New(poolSize int, opt ...Options) *Scheduler {
 	ctx, cancel := context.WithCancel(context.Background())
-	return &Scheduler{
-		tasks:  make(map[string]*Task),
-		pool:   make(chan struct{}, poolSize),
-		ctx:    ctx,
-		cancel: cancel,
+	s := &Scheduler{
+		tasks:    make(map[string]*Task),
+		pool:     make(chan struct{}, poolSize),
+		ctx:      ctx,
+		cancel:   cancel,
+		inflight: make(map[string]int),
+	}
+	s.jobCtx, s.jobCancel = context.WithCancel(context.Background())
+	if len(opt) > 0 {
+		s.opt = opt[0]
+	}
+	if s.opt.HistorySize <= 0 {
+		s.opt.HistorySize = DefaultHistorySize
 	}
+	return s
 }
 
-// Add 添加任务
-func (s *Scheduler) Add(id string, schedule Schedule, job Job) {
+// Add 添加任务，同 id 的旧任务会被替换；Stop 之后添加的任务被忽略
+func (s *Scheduler) Add(id string, schedule Schedule, job Job, opt ...TaskOptions) {
+	s.add(id, schedule, job, func(context.Context) error {
+		job.Run()
+		return nil
+	}, opt, nil, nil)
+}
+
+// AddErrorJob 添加返回错误的任务，错误记录在执行历史中并触发 OnError
+func (s *Scheduler) AddErrorJob(id string, schedule Schedule, job ErrorJob, opt ...TaskOptions) {
+	s.add(id, schedule, nil, func(context.Context) error { return job.Run() }, opt, nil, nil)
+}
+
+// AddContextJob 添加感知 ctx 的任务，TaskOptions.Timeout 通过 ctx 传递
+func (s *Scheduler) AddContextJob(id string, schedule Schedule, job ContextJob, opt ...TaskOptions) {
+	s.add(id, schedule, nil, job.Run, opt, nil, nil)
+}
+
+func (s *Scheduler) add(id string, schedule Schedule, job Job, fn ContextJobFunc, opt []TaskOptions, record *JobRecord, catchUp []time.Time) {
 	s.taskMu.Lock()
-	defer s.taskMu.Unlock()
-	if old, ok := s.tasks[id]; ok {
+	if s.closed {
+		s.taskMu.Unlock()
+		return
+	}
+	old, replaced := s.tasks[id]
+	if replaced {
 		old.cancel()
 	}
 	ctx, cancel := context.WithCancel(s.ctx)
+	jobCtx, jobCancel := context.WithCancel(s.jobCtx)
 	t := &Task{
 		ID:       id,
 		Schedule: schedule,
 		Job:      job,
 		ctx:      ctx,
-		cancel:   cancel,
+		jobCtx:   jobCtx,
+		cancel:   func() { cancel(); jobCancel() },
+		fn:       recovered(fn),
+		running:  make(chan struct{}, 1),
+		status:   newTaskStatus(s.opt.HistorySize),
+		record:   record,
+		wake:     make(chan struct{}, 1),
+	}
+	if len(opt) > 0 {
+		t.opt = opt[0]
 	}
 	s.tasks[id] = t
 	s.wg.Add(1)
-	go s.runTask(t)
+	go s.runTask(t, catchUp)
+	s.taskMu.Unlock()
+	// 持久化任务被非持久化任务替换时删除旧记录
+	if replaced && old.record != nil && record == nil {
+		s.unstore(old)
+	}
 }
 
-// Remove 移除任务
+// Remove 移除任务，正在执行的实例不受影响，其 ctx 被取消
 func (s *Scheduler) Remove(id string) {
 	s.taskMu.Lock()
 	t, ok := s.tasks[id]
@@ -161,93 +242,310 @@ func (s *Scheduler) Remove(id string) {
 		delete(s.tasks, id)
 	}
 	s.taskMu.Unlock()
-	if ok {
-		s.wg.Done()
+	if ok && t.record != nil {
+		s.unstore(t)
 	}
 }
 
-// Stop 优雅停止
+// Pause 暂停任务，暂停期间到点的触发被跳过
+func (s *Scheduler) Pause(id string) error {
+	return s.setPaused(id, true)
+}
+
+// Resume 恢复已暂停的任务
+func (s *Scheduler) Resume(id string) error {
+	return s.setPaused(id, false)
+}
+
+func (s *Scheduler) setPaused(id string, paused bool) error {
+	t, ok := s.task(id)
+	if !ok {
+		return ErrTaskNotFound
+	}
+	t.mu.Lock()
+	t.paused = paused
+	t.mu.Unlock()
+	return nil
+}
+
+// TriggerNow 立即触发一次执行，不影响原有计划；暂停中的任务同样执行
+// 仍遵循并发策略、协程池与分布式锁
+func (s *Scheduler) TriggerNow(id string) error {
+	s.taskMu.RLock()
+	defer s.taskMu.RUnlock()
+	t, ok := s.tasks[id]
+	if !ok || s.closed {
+		return ErrTaskNotFound
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		now := time.Now()
+		s.fire(t, now, now)
+	}()
+	return nil
+}
+
+// UpdateSchedule 替换任务的调度并立即按新调度重新计算下一次触发时间
+func (s *Scheduler) UpdateSchedule(id string, schedule Schedule) error {
+	s.taskMu.RLock()
+	defer s.taskMu.RUnlock()
+	t, ok := s.tasks[id]
+	if !ok || s.closed {
+		return ErrTaskNotFound
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Schedule = schedule
+	if t.idle {
+		// 原调度已结束，重新启动
+		t.idle = false
+		s.wg.Add(1)
+		go s.runTask(t, nil)
+		return nil
+	}
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (s *Scheduler) task(id string) (*Task, bool) {
+	s.taskMu.RLock()
+	defer s.taskMu.RUnlock()
+	t, ok := s.tasks[id]
+	return t, ok
+}
+
+// Stop 停止触发，取消任务 ctx 并等待执行中的任务结束，可重复调用
 func (s *Scheduler) Stop() {
+	s.halt()
+	s.jobCancel()
+	s.jobs.Wait()
+}
+
+// StopContext 停止触发并等待执行中的任务结束，可重复调用
+// ctx 到期时取消传给任务的 ctx 并立即返回 *DrainError，列出仍在执行的任务
+func (s *Scheduler) StopContext(ctx context.Context) error {
+	s.halt()
+	done := make(chan struct{})
+	go func() {
+		s.jobs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		s.jobCancel()
+		return nil
+	case <-ctx.Done():
+	}
+	s.jobCancel()
+	return &DrainError{Running: s.running(), Err: ctx.Err()}
+}
+
+// halt 标记关闭，停止全部调度循环并等待其退出
+func (s *Scheduler) halt() {
+	s.taskMu.Lock()
+	s.closed = true
+	s.taskMu.Unlock()
 	s.cancel()
 	s.wg.Wait()
 }
 
-func (s *Scheduler) runTask(t *Task) {
+// running 返回仍有实例在执行的任务 ID
+func (s *Scheduler) running() []string {
+	s.jobMu.Lock()
+	defer s.jobMu.Unlock()
+	ids := make([]string, 0, len(s.inflight))
+	for id := range s.inflight {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// DrainError StopContext 到期时仍在执行的任务
+type DrainError struct {
+	Running []string
+	Err     error
+}
+
+func (e *DrainError) Error() string {
+	return fmt.Sprintf("gschedule: stop: %v, still running: %s", e.Err, strings.Join(e.Running, ", "))
+}
+
+func (e *DrainError) Unwrap() error { return e.Err }
+
+// runTask 按调度循环触发，catchUp 为启动时需补跑的计划触发时间
+func (s *Scheduler) runTask(t *Task, catchUp []time.Time) {
 	defer s.wg.Done()
-	timer := time.NewTimer(0)
-	<-timer.C
+	for _, at := range catchUp {
+		if !s.fire(t, at, time.Now()) {
+			return
+		}
+	}
 	for {
-		next := t.Schedule.Next(time.Now())
+		sched, _ := t.state()
+		next := sched.Next(time.Now())
+		t.status.setNext(next)
 		if next.IsZero() {
-			return // 单次 Delay 结束
-		}
-		wait := time.Until(next)
-		if wait > 0 {
-			select {
-			case <-time.After(wait):
-			case <-t.ctx.Done():
-				return
+			if t.finish() {
+				return // 单次 Delay 结束
 			}
+			continue
 		}
+		timer := time.NewTimer(time.Until(next))
 		select {
-		case s.pool <- struct{}{}:
-			// 拿到令牌
-			go func() {
-				t.Job.Run()
-				<-s.pool
-			}()
+		case <-timer.C:
+		case <-t.wake:
+			timer.Stop()
+			continue
 		case <-t.ctx.Done():
+			timer.Stop()
+			return
+		}
+		if _, paused := t.state(); paused {
+			continue
+		}
+		if !s.fire(t, next, next) {
 			return
 		}
 	}
 }
 
-// --------------- 辅助函数 ----------------
-func parseField(s string, min, max int) ([]int, error) {
-	var res []int
-	if s == "*" {
-		for i := min; i <= max; i++ {
-			res = append(res, i)
-		}
-		return res, nil
-	}
-	parts := strings.SplitSeq(s, ",")
-	for part := range parts {
-		if strings.Contains(part, "/") {
-			// 步进，简化实现：只支持 */step
-			sp := strings.Split(part, "/")
-			if len(sp) != 2 || sp[0] != "*" {
-				return nil, fmt.Errorf("invalid step %s", part)
-			}
-			step, _ := strconv.Atoi(sp[1])
-			for i := min; i <= max; i += step {
-				res = append(res, i)
-			}
-			continue
+func (t *Task) state() (Schedule, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.Schedule, t.paused
+}
+
+// finish 调度结束时标记 idle；期间调度已被更新时返回 false 继续运行
+func (t *Task) finish() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	select {
+	case <-t.wake:
+		return false
+	default:
+	}
+	t.idle = true
+	return true
+}
+
+// fire 按并发、misfire 策略与分布式锁触发计划时间为 at 的一次执行，
+// due 为判断 misfire 的基准时间，返回 false 表示任务已停止
+//
+// 租约在本地策略放行、即将执行时才获取：先取租约再按 SkipIfRunning 跳过会使该次触发
+// 在所有实例上都不执行，按 DelayIfRunning 持有租约等待则可能超过租约时长而被其他实例重复执行
+func (s *Scheduler) fire(t *Task, at, due time.Time) bool {
+	if !t.lock() {
+		if t.ctx.Err() != nil {
+			return false
 		}
-		if strings.Contains(part, "-") {
-			rng := strings.Split(part, "-")
-			if len(rng) != 2 {
-				return nil, fmt.Errorf("invalid range %s", part)
-			}
-			start, _ := strconv.Atoi(rng[0])
-			end, _ := strconv.Atoi(rng[1])
-			for i := start; i <= end; i++ {
-				res = append(res, i)
-			}
-			continue
+		t.status.skip()
+		return true
+	}
+	if !s.acquire(t, due) {
+		t.unlock()
+		if t.ctx.Err() != nil {
+			return false
 		}
-		n, err := strconv.Atoi(part)
-		if err != nil {
-			return nil, err
+		t.status.misfire()
+		return true
+	}
+	if !s.tryLock(t, at) {
+		<-s.pool
+		t.unlock()
+		if t.ctx.Err() != nil {
+			return false
 		}
-		res = append(res, n)
+		t.status.contend()
+		return true
+	}
+	// 拿到令牌与租约
+	s.track(t.ID, 1)
+	go func() {
+		defer s.track(t.ID, -1)
+		s.exec(t)
+		t.unlock()
+		<-s.pool
+	}()
+	return true
+}
+
+// track 登记执行中的实例
+func (s *Scheduler) track(id string, delta int) {
+	s.jobMu.Lock()
+	defer s.jobMu.Unlock()
+	if delta > 0 {
+		s.jobs.Add(1)
+	} else {
+		defer s.jobs.Done()
+	}
+	if s.inflight[id] += delta; s.inflight[id] <= 0 {
+		delete(s.inflight, id)
 	}
-	sort.Ints(res)
-	return res, nil
 }
 
-func contains(arr []int, v int) bool {
-	i := sort.SearchInts(arr, v)
-	return i < len(arr) && arr[i] == v
+// unstore 从存储中删除任务记录，之后结束的执行不再写回
+func (s *Scheduler) unstore(t *Task) {
+	t.detach()
+	if s.opt.Store == nil {
+		return
+	}
+	if err := s.opt.Store.Delete(t.ID); err != nil && s.opt.OnError != nil {
+		s.opt.OnError(t.ID, err)
+	}
+}
+
+// exec 执行一次任务，记录状态并触发回调
+func (s *Scheduler) exec(t *Task) {
+	if s.opt.BeforeRun != nil {
+		s.opt.BeforeRun(t.ID)
+	}
+	ctx := t.jobCtx
+	if t.opt.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.opt.Timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	t.status.begin(start)
+	err := t.fn(ctx)
+	if ctx.Err() == context.DeadlineExceeded && (err == nil || errors.Is(err, context.DeadlineExceeded)) {
+		err = ErrTimeout
+	}
+	rec := RunRecord{Start: start, End: time.Now(), Err: err}
+	t.status.finish(rec)
+	s.persist(t, start)
+	if err != nil && s.opt.OnError != nil {
+		s.opt.OnError(t.ID, err)
+	}
+	if s.opt.AfterRun != nil {
+		s.opt.AfterRun(t.ID, rec)
+	}
+}
+
+// List 返回全部任务的状态快照，按 ID 排序
+func (s *Scheduler) List() []TaskInfo {
+	s.taskMu.RLock()
+	res := make([]TaskInfo, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		res = append(res, t.info())
+	}
+	s.taskMu.RUnlock()
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	return res
+}
+
+// Get 返回指定任务的状态快照
+func (s *Scheduler) Get(id string) (TaskInfo, bool) {
+	s.taskMu.RLock()
+	t, ok := s.tasks[id]
+	s.taskMu.RUnlock()
+	if !ok {
+		return TaskInfo{}, false
+	}
+	return t.info(), true
 }
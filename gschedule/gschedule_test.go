@@ -0,0 +1,266 @@
+package gschedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// once 在 at 触发一次
+type once struct{ at time.Time }
+
+func (o once) Next(t time.Time) time.Time {
+	if t.Before(o.at) {
+		return o.at
+	}
+	return time.Time{}
+}
+
+// probe 记录执行中与已开始的实例数，执行持续 d 或直到 ctx 取消
+type probe struct {
+	active  atomic.Int64
+	started atomic.Int64
+	d       time.Duration
+}
+
+func (p *probe) Run(ctx context.Context) error {
+	p.started.Add(1)
+	p.active.Add(1)
+	defer p.active.Add(-1)
+	timer := time.NewTimer(p.d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	return nil
+}
+
+func runs(s *Scheduler, id string) int64 {
+	info, _ := s.Get(id)
+	return info.Runs
+}
+
+// 在执行中的任务上并发调用各项操作，需在 -race 下通过
+func TestConcurrentOperations(t *testing.T) {
+	s := New(4)
+	p := &probe{d: time.Millisecond}
+	policies := []Concurrency{AllowConcurrent, SkipIfRunning, DelayIfRunning}
+	ids := make([]string, 8)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("task%d", i)
+		s.AddContextJob(ids[i], Every(2*time.Millisecond), p, TaskOptions{Concurrency: policies[i%len(policies)]})
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rnd := rand.New(rand.NewSource(seed))
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				id := ids[rnd.Intn(len(ids))]
+				var err error
+				switch rnd.Intn(9) {
+				case 0:
+					s.AddContextJob(id, Every(time.Duration(1+rnd.Intn(3))*time.Millisecond), p,
+						TaskOptions{Concurrency: policies[rnd.Intn(len(policies))]})
+				case 1:
+					s.Remove(id)
+				case 2:
+					err = s.Pause(id)
+				case 3:
+					err = s.Resume(id)
+				case 4:
+					err = s.TriggerNow(id)
+				case 5:
+					err = s.UpdateSchedule(id, Every(time.Duration(1+rnd.Intn(3))*time.Millisecond))
+				case 6:
+					err = s.UpdateSchedule(id, once{time.Now().Add(time.Millisecond)})
+				case 7:
+					s.Get(id)
+				case 8:
+					s.List()
+				}
+				if err != nil && !errors.Is(err, ErrTaskNotFound) {
+					t.Errorf("unexpected error: %v", err)
+				}
+				time.Sleep(time.Duration(rnd.Intn(200)) * time.Microsecond)
+			}
+		}(int64(w))
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	// Stop 与其余操作并发进行
+	s.Stop()
+	if n := p.active.Load(); n != 0 {
+		t.Fatalf("%d jobs still running after Stop", n)
+	}
+	started := p.started.Load()
+	time.Sleep(50 * time.Millisecond)
+	close(stop)
+	wg.Wait()
+	if n := p.started.Load() - started; n != 0 {
+		t.Fatalf("%d jobs started after Stop", n)
+	}
+	if started == 0 {
+		t.Fatal("no job ran")
+	}
+	for _, id := range ids {
+		if err := s.TriggerNow(id); !errors.Is(err, ErrTaskNotFound) {
+			t.Errorf("TriggerNow after Stop = %v", err)
+		}
+	}
+}
+
+func TestRemoveCancelsRunningJob(t *testing.T) {
+	s := New(2)
+	defer s.Stop()
+	started, done := make(chan struct{}), make(chan struct{})
+	s.AddContextJob("job", Every(time.Hour), ContextJobFunc(func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		close(done)
+		return ctx.Err()
+	}))
+	if err := s.TriggerNow("job"); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	s.Remove("job")
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("removed job was not cancelled")
+	}
+	if _, ok := s.Get("job"); ok {
+		t.Fatal("task still listed after Remove")
+	}
+	if err := s.TriggerNow("job"); !errors.Is(err, ErrTaskNotFound) {
+		t.Fatalf("TriggerNow after Remove = %v", err)
+	}
+}
+
+func TestReplaceWhileRunning(t *testing.T) {
+	s := New(2)
+	defer s.Stop()
+	oldDone := make(chan struct{})
+	started := make(chan struct{})
+	s.AddContextJob("job", Every(time.Hour), ContextJobFunc(func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		close(oldDone)
+		return nil
+	}))
+	s.TriggerNow("job")
+	<-started
+	var n atomic.Int64
+	s.Add("job", Every(5*time.Millisecond), JobFunc(func() { n.Add(1) }))
+	select {
+	case <-oldDone:
+	case <-time.After(time.Second):
+		t.Fatal("replaced job was not cancelled")
+	}
+	waitFor(t, time.Second, func() bool { return n.Load() >= 2 })
+}
+
+func TestPauseResume(t *testing.T) {
+	s := New(2)
+	defer s.Stop()
+	var n atomic.Int64
+	s.Add("job", Every(2*time.Millisecond), JobFunc(func() { n.Add(1) }))
+	waitFor(t, time.Second, func() bool { return n.Load() >= 2 })
+
+	if err := s.Pause("job"); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := s.Get("job"); !info.Paused {
+		t.Fatal("task not reported as paused")
+	}
+	time.Sleep(20 * time.Millisecond) // 等待暂停前已触发的执行结束
+	paused := n.Load()
+	time.Sleep(50 * time.Millisecond)
+	if got := n.Load(); got != paused {
+		t.Fatalf("%d runs while paused", got-paused)
+	}
+
+	// 暂停中 TriggerNow 仍然执行
+	if err := s.TriggerNow("job"); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, time.Second, func() bool { return n.Load() == paused+1 })
+
+	if err := s.Resume("job"); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, time.Second, func() bool { return n.Load() >= paused+3 })
+
+	if err := s.Pause("missing"); !errors.Is(err, ErrTaskNotFound) {
+		t.Fatalf("Pause(missing) = %v", err)
+	}
+	if err := s.Resume("missing"); !errors.Is(err, ErrTaskNotFound) {
+		t.Fatalf("Resume(missing) = %v", err)
+	}
+}
+
+func TestUpdateSchedule(t *testing.T) {
+	s := New(2)
+	defer s.Stop()
+	s.Add("job", Every(time.Hour), JobFunc(func() {}))
+	if err := s.UpdateSchedule("job", Every(2*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	// 新调度立即生效，不必等待原来一小时的计时
+	waitFor(t, time.Second, func() bool { return runs(s, "job") >= 2 })
+
+	// 调度结束后更新会重新启动
+	if err := s.UpdateSchedule("job", once{time.Now().Add(5 * time.Millisecond)}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, time.Second, func() bool { info, _ := s.Get("job"); return info.Next.IsZero() })
+	time.Sleep(20 * time.Millisecond) // 等待最后一次执行计入
+	before := runs(s, "job")
+	time.Sleep(20 * time.Millisecond)
+	if runs(s, "job") != before {
+		t.Fatal("finished schedule kept running")
+	}
+	if err := s.UpdateSchedule("job", Every(2*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, time.Second, func() bool { return runs(s, "job") >= before+2 })
+
+	if err := s.UpdateSchedule("missing", Every(time.Second)); !errors.Is(err, ErrTaskNotFound) {
+		t.Fatalf("UpdateSchedule(missing) = %v", err)
+	}
+}
+
+func TestStopWaitsForRunningJobs(t *testing.T) {
+	s := New(2)
+	var finished atomic.Bool
+	started := make(chan struct{})
+	s.Add("job", Every(time.Hour), JobFunc(func() {
+		close(started)
+		time.Sleep(30 * time.Millisecond)
+		finished.Store(true)
+	}))
+	s.TriggerNow("job")
+	<-started
+	s.Stop()
+	if !finished.Load() {
+		t.Fatal("Stop returned before the running job finished")
+	}
+	s.Stop() // 可重复调用
+	s.Add("late", Every(time.Millisecond), JobFunc(func() { t.Error("task added after Stop ran") }))
+	time.Sleep(10 * time.Millisecond)
+}
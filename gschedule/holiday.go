@@ -0,0 +1,286 @@
+package gschedule
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultWorkdayMarkers ICS 事件标题以这些字样结尾时视为调休上班日，如 "国庆节补班"、"元旦（班）"
+var DefaultWorkdayMarkers = []string{"补班", "上班", "调休上班", "(班)", "（班）"}
+
+// workdayNegations 标记前出现这些字样时不是上班日，如 "不上班"、"无需补班"
+var workdayNegations = []string{"不", "无需", "无须", "不用", "免"}
+
+type civilDate struct {
+	y int
+	m time.Month
+	d int
+}
+
+func dateOf(t time.Time) civilDate {
+	y, m, d := t.Date()
+	return civilDate{y, m, d}
+}
+
+// HolidayCalendar 节假日日历，包含的日期为休息日：
+// 法定节假日，以及不是调休上班日的周末
+type HolidayCalendar struct {
+	mu       sync.RWMutex
+	weekend  Calendar
+	holidays map[civilDate]string
+	workdays map[civilDate]string
+}
+
+// NewHolidayCalendar 创建节假日日历，weekend 为 nil 时周末为周六、周日
+func NewHolidayCalendar(weekend Calendar) *HolidayCalendar {
+	if weekend == nil {
+		weekend = Weekends()
+	}
+	return &HolidayCalendar{
+		weekend:  weekend,
+		holidays: make(map[civilDate]string),
+		workdays: make(map[civilDate]string),
+	}
+}
+
+// AddHoliday 添加节假日
+func (c *HolidayCalendar) AddHoliday(day time.Time, name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.holidays[dateOf(day)] = name
+}
+
+// AddWorkday 添加调休上班日（周末也需上班）
+func (c *HolidayCalendar) AddWorkday(day time.Time, name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.workdays[dateOf(day)] = name
+}
+
+// Contains t 所在日期是否休息
+func (c *HolidayCalendar) Contains(t time.Time) bool {
+	return !c.IsBusinessDay(t)
+}
+
+// IsBusinessDay t 所在日期是否工作日
+func (c *HolidayCalendar) IsBusinessDay(t time.Time) bool {
+	d := dateOf(t)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if _, ok := c.holidays[d]; ok {
+		return false
+	}
+	if _, ok := c.workdays[d]; ok {
+		return true
+	}
+	return !c.weekend.Contains(t)
+}
+
+// Holiday 返回 t 所在日期的节假日名称
+func (c *HolidayCalendar) Holiday(t time.Time) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	name, ok := c.holidays[dateOf(t)]
+	return name, ok
+}
+
+// LoadHolidays 按扩展名（.json、.ics）从文件加载节假日到 c
+func (c *HolidayCalendar) LoadHolidays(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return c.LoadJSON(f)
+	case ".ics", ".ical":
+		return c.LoadICS(f)
+	}
+	return fmt.Errorf("gschedule: unsupported holiday file %s", path)
+}
+
+// holidayEntry JSON 条目："2026-10-01"、{"date":"2026-10-01","name":"国庆节"}
+// 或 {"start":"2026-10-01","end":"2026-10-08","name":"国庆节"}（含首尾）
+type holidayEntry struct {
+	Date  string `json:"date"`
+	Start string `json:"start"`
+	End   string `json:"end"`
+	Name  string `json:"name"`
+}
+
+func (e *holidayEntry) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		return json.Unmarshal(b, &e.Date)
+	}
+	type plain holidayEntry
+	return json.Unmarshal(b, (*plain)(e))
+}
+
+// LoadJSON 加载 JSON 格式的节假日：
+//
+//	{"holidays": ["2026-01-01", {"start": "2026-10-01", "end": "2026-10-08", "name": "国庆节"}],
+//	 "workdays": [{"date": "2026-10-10", "name": "国庆节调休"}]}
+func (c *HolidayCalendar) LoadJSON(r io.Reader) error {
+	var doc struct {
+		Holidays []holidayEntry `json:"holidays"`
+		Workdays []holidayEntry `json:"workdays"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return fmt.Errorf("gschedule: parse holidays: %w", err)
+	}
+	for _, list := range []struct {
+		entries []holidayEntry
+		add     func(time.Time, string)
+	}{{doc.Holidays, c.AddHoliday}, {doc.Workdays, c.AddWorkday}} {
+		for _, e := range list.entries {
+			start, end := e.Start, e.End
+			if e.Date != "" {
+				start, end = e.Date, e.Date
+			}
+			if err := addRange(start, end, e.Name, list.add); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func addRange(start, end, name string, add func(time.Time, string)) error {
+	from, err := time.Parse(time.DateOnly, start)
+	if err != nil {
+		return fmt.Errorf("gschedule: invalid date %q", start)
+	}
+	to := from
+	if end != "" {
+		if to, err = time.Parse(time.DateOnly, end); err != nil {
+			return fmt.Errorf("gschedule: invalid date %q", end)
+		}
+	}
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		add(d, name)
+	}
+	return nil
+}
+
+// LoadICS 加载 iCalendar 格式的节假日，每个 VEVENT 覆盖 DTSTART 到 DTEND（不含）的日期；
+// SUMMARY 以 DefaultWorkdayMarkers 中字样结尾（且前面不是否定词）的事件视为调休上班日
+func (c *HolidayCalendar) LoadICS(r io.Reader) error {
+	lines, err := unfoldICS(r)
+	if err != nil {
+		return err
+	}
+	var start, end, summary string
+	inEvent := false
+	for _, line := range lines {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		prop, _, _ := strings.Cut(name, ";")
+		switch strings.ToUpper(prop) {
+		case "BEGIN":
+			if strings.EqualFold(value, "VEVENT") {
+				inEvent, start, end, summary = true, "", "", ""
+			}
+		case "DTSTART":
+			start = value
+		case "DTEND":
+			end = value
+		case "SUMMARY":
+			summary = value
+		case "END":
+			if !inEvent || !strings.EqualFold(value, "VEVENT") {
+				continue
+			}
+			inEvent = false
+			if err := c.addICSEvent(start, end, summary); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *HolidayCalendar) addICSEvent(start, end, summary string) error {
+	from, err := parseICSDate(start)
+	if err != nil {
+		return err
+	}
+	to := from
+	if end != "" {
+		if to, err = parseICSDate(end); err != nil {
+			return err
+		}
+		// DTEND 不含
+		if to.After(from) {
+			to = to.AddDate(0, 0, -1)
+		}
+	}
+	add := c.AddHoliday
+	if isWorkdaySummary(summary) {
+		add = c.AddWorkday
+	}
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		add(d, summary)
+	}
+	return nil
+}
+
+// isWorkdaySummary 标题是否表示调休上班日
+func isWorkdaySummary(summary string) bool {
+	s := strings.TrimSpace(summary)
+	for _, m := range DefaultWorkdayMarkers {
+		rest, ok := strings.CutSuffix(s, m)
+		if !ok {
+			continue
+		}
+		rest = strings.TrimSpace(rest)
+		negated := false
+		for _, n := range workdayNegations {
+			if strings.HasSuffix(rest, n) {
+				negated = true
+				break
+			}
+		}
+		if !negated {
+			return true
+		}
+	}
+	return false
+}
+
+// parseICSDate 解析 20261001 或 20261001T000000[Z]，只取日期部分
+func parseICSDate(s string) (time.Time, error) {
+	if len(s) < 8 {
+		return time.Time{}, fmt.Errorf("gschedule: invalid ics date %q", s)
+	}
+	t, err := time.Parse("20060102", s[:8])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("gschedule: invalid ics date %q", s)
+	}
+	return t, nil
+}
+
+// unfoldICS 读取并展开折行（以空格或制表符开头的行接续上一行）
+func unfoldICS(r io.Reader) ([]string, error) {
+	var lines []string
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, sc.Err()
+}
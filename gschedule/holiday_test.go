@@ -0,0 +1,194 @@
+package gschedule
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func day(mo time.Month, d int) time.Time { return time.Date(2024, mo, d, 12, 0, 0, 0, time.UTC) }
+
+func TestHolidayCalendar(t *testing.T) {
+	c := chinaOctober2024()
+	for _, tc := range []struct {
+		at       time.Time
+		business bool
+	}{
+		{day(9, 27), true},   // 周五
+		{day(9, 28), false},  // 周六
+		{day(9, 29), true},   // 周日补班
+		{day(10, 1), false},  // 节假日
+		{day(10, 7), false},  // 节假日（周一）
+		{day(10, 8), true},   // 节后周二
+		{day(10, 12), true},  // 周六补班
+		{day(10, 13), false}, // 周日
+	} {
+		if got := c.IsBusinessDay(tc.at); got != tc.business || c.Contains(tc.at) == tc.business {
+			t.Errorf("%s: IsBusinessDay = %v, want %v", tc.at.Format(time.DateOnly), got, tc.business)
+		}
+	}
+	if name, ok := c.Holiday(day(10, 3)); !ok || name != "国庆节" {
+		t.Errorf("Holiday = %q, %v", name, ok)
+	}
+	if _, ok := c.Holiday(day(9, 29)); ok {
+		t.Error("make-up workday reported as holiday")
+	}
+	// 同一天既是节假日又是补班日时以节假日为准
+	c.AddWorkday(day(10, 2), "冲突")
+	if c.IsBusinessDay(day(10, 2)) {
+		t.Error("holiday overridden by workday")
+	}
+	// 自定义周末
+	fri := NewHolidayCalendar(Weekends(time.Friday))
+	if fri.IsBusinessDay(day(9, 27)) || !fri.IsBusinessDay(day(9, 28)) {
+		t.Error("custom weekend ignored")
+	}
+}
+
+func TestLoadHolidaysJSON(t *testing.T) {
+	c := NewHolidayCalendar(nil)
+	err := c.LoadJSON(strings.NewReader(`{
+		"holidays": ["2024-01-01", {"date": "2024-05-01", "name": "劳动节"}, {"start": "2024-10-01", "end": "2024-10-07", "name": "国庆节"}],
+		"workdays": [{"date": "2024-09-29", "name": "国庆节补班"}, "2024-10-12"]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range []time.Time{day(1, 1), day(5, 1), day(10, 1), day(10, 4), day(10, 7)} {
+		if c.IsBusinessDay(d) {
+			t.Errorf("%s is not a holiday", d.Format(time.DateOnly))
+		}
+	}
+	for _, d := range []time.Time{day(9, 29), day(10, 8), day(10, 12)} {
+		if !c.IsBusinessDay(d) {
+			t.Errorf("%s is not a business day", d.Format(time.DateOnly))
+		}
+	}
+	if name, _ := c.Holiday(day(10, 5)); name != "国庆节" {
+		t.Errorf("Holiday = %q", name)
+	}
+	for _, doc := range []string{
+		`{"holidays": ["2024/01/01"]}`,
+		`{"holidays": [{"start": "2024-10-01", "end": "10-07"}]}`,
+		`{"holidays": [{"name": "无日期"}]}`,
+		`{"holidays": "2024-01-01"}`,
+		`not json`,
+	} {
+		if err := NewHolidayCalendar(nil).LoadJSON(strings.NewReader(doc)); err == nil {
+			t.Errorf("%s: expected error", doc)
+		}
+	}
+}
+
+func TestLoadHolidaysICS(t *testing.T) {
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20241001",
+		"DTEND;VALUE=DATE:20241008",
+		"SUMMARY:国庆",
+		" 节",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20240929",
+		"SUMMARY:国庆节补班",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART:20241012T000000Z",
+		"DTEND:20241012T235959Z",
+		"SUMMARY:国庆节 (班)",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20240505",
+		"DTEND;VALUE=DATE:20240506",
+		"SUMMARY:劳动节不上班",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20240914",
+		"DTEND;VALUE=DATE:20240915",
+		"SUMMARY:中秋节 无需补班",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+	c := NewHolidayCalendar(nil)
+	if err := c.LoadICS(strings.NewReader(ics)); err != nil {
+		t.Fatal(err)
+	}
+	if name, ok := c.Holiday(day(10, 7)); !ok || name != "国庆节" {
+		t.Errorf("Holiday(10-07) = %q, %v", name, ok)
+	}
+	// DTEND 不含
+	if !c.IsBusinessDay(day(10, 8)) {
+		t.Error("DTEND treated as inclusive")
+	}
+	if !c.IsBusinessDay(day(9, 29)) || !c.IsBusinessDay(day(10, 12)) {
+		t.Error("make-up workday not loaded")
+	}
+	// "不上班" 与 "无需补班" 不是调休上班日
+	if _, ok := c.Holiday(day(5, 5)); !ok {
+		t.Error("劳动节不上班 loaded as workday")
+	}
+	if _, ok := c.Holiday(day(9, 14)); !ok {
+		t.Error("中秋节 无需补班 loaded as workday")
+	}
+	for _, bad := range []string{
+		"BEGIN:VEVENT\nDTSTART:2024\nEND:VEVENT",
+		"BEGIN:VEVENT\nDTSTART:20241001\nDTEND:2024-10-02\nEND:VEVENT",
+	} {
+		if err := NewHolidayCalendar(nil).LoadICS(strings.NewReader(bad)); err == nil {
+			t.Errorf("%q: expected error", bad)
+		}
+	}
+}
+
+func TestIsWorkdaySummary(t *testing.T) {
+	for summary, want := range map[string]bool{
+		"补班":       true,
+		"上班":       true,
+		"国庆节补班":    true,
+		"春节调休上班":   true,
+		"元旦（班）":    true,
+		"元旦 (班) ":  true,
+		"国庆节":      false,
+		"不上班":      false,
+		"春节放假 不上班": false,
+		"无需补班":     false,
+		"不用上班":     false,
+		"免补班":      false,
+		"上班族节日":    false,
+		"（休）":      false,
+	} {
+		if got := isWorkdaySummary(summary); got != want {
+			t.Errorf("isWorkdaySummary(%q) = %v, want %v", summary, got, want)
+		}
+	}
+}
+
+func TestLoadHolidaysFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	c := NewHolidayCalendar(nil)
+	if err := c.LoadHolidays(write("h.JSON", `{"holidays": ["2024-01-01"]}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.LoadHolidays(write("h.ics", "BEGIN:VEVENT\nDTSTART:20240501\nSUMMARY:劳动节\nEND:VEVENT\n")); err != nil {
+		t.Fatal(err)
+	}
+	if c.IsBusinessDay(day(1, 1)) || c.IsBusinessDay(day(5, 1)) {
+		t.Error("holidays from files not loaded")
+	}
+	if err := c.LoadHolidays(write("h.txt", "2024-01-01")); err == nil {
+		t.Error("unsupported extension accepted")
+	}
+	if err := c.LoadHolidays(filepath.Join(dir, "missing.json")); !os.IsNotExist(err) {
+		t.Errorf("missing file: %v", err)
+	}
+}
@@ -0,0 +1,221 @@
+package gschedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultLockTTL 默认租约时长
+const DefaultLockTTL = time.Minute
+
+// Locker 分布式锁，多个实例共享同一 Locker 时每次触发只有一个实例执行
+//
+// key 由任务 ID 与计划触发时间组成，租约到期后自动失效，执行结束后不主动释放，
+// 以免落后的实例在同一触发上重复执行。计划时间需在各实例间一致，
+// 适用于 Cron 等按墙上时间计算的调度，不适用于 Every 这类相对启动时间的间隔
+type Locker interface {
+	// TryLock 尝试获取 key 的租约，返回 false 表示已被其他实例持有
+	TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+// lockKey 任务 ID 与计划触发时间（秒）组成的锁 key
+func lockKey(id string, at time.Time) string {
+	return fmt.Sprintf("%s@%d", id, at.Unix())
+}
+
+// tryLock 执行前咨询 Options.Locker，出错时按未获得处理，避免重复执行
+func (s *Scheduler) tryLock(t *Task, at time.Time) bool {
+	if s.opt.Locker == nil {
+		return true
+	}
+	ttl := s.opt.LockTTL
+	if ttl <= 0 {
+		ttl = DefaultLockTTL
+	}
+	ok, err := s.opt.Locker.TryLock(t.ctx, lockKey(t.ID, at), ttl)
+	if err != nil && s.opt.OnError != nil {
+		s.opt.OnError(t.ID, err)
+	}
+	return ok && err == nil
+}
+
+// MemoryLocker 进程内锁，用于测试或单进程内多个调度器
+type MemoryLocker struct {
+	mu     sync.Mutex
+	leases map[string]time.Time
+}
+
+// NewMemoryLocker 创建进程内锁
+func NewMemoryLocker() *MemoryLocker {
+	return &MemoryLocker{leases: make(map[string]time.Time)}
+}
+
+func (m *MemoryLocker) TryLock(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for k, exp := range m.leases {
+		if !now.Before(exp) {
+			delete(m.leases, k)
+		}
+	}
+	if _, ok := m.leases[key]; ok {
+		return false, nil
+	}
+	m.leases[key] = now.Add(ttl)
+	return true, nil
+}
+
+// FileLocker 基于目录的文件锁，用于同一主机上的多个进程
+// 每个租约对应目录下一个文件，内容为到期时间，通过硬链接原子创建；
+// 过期租约由唯一取得接管标记的实例以 rename 原子替换，期间文件始终存在
+type FileLocker struct {
+	dir   string
+	mu    sync.Mutex
+	swept time.Time
+}
+
+// staleMarkerAge 接管标记存在超过该时长视为持有者已崩溃，由 sweep 清理
+const staleMarkerAge = time.Minute
+
+// NewFileLocker 创建以 dir 为锁目录的文件锁
+func NewFileLocker(dir string) (*FileLocker, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileLocker{dir: dir}, nil
+}
+
+func (l *FileLocker) TryLock(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	l.sweep(now)
+	path := filepath.Join(l.dir, url.PathEscape(key)+".lock")
+	for range 2 {
+		err := l.create(path, now.Add(ttl))
+		if err == nil {
+			return true, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return false, err
+		}
+		exp, err := readExpiry(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			// 刚被清理，重试创建
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		if now.Before(exp) {
+			return false, nil
+		}
+		// 租约已过期，接管成功才算获得
+		return l.takeOver(path, exp, func() error {
+			tmp, err := l.writeTemp(now.Add(ttl))
+			if err != nil {
+				return err
+			}
+			defer os.Remove(tmp)
+			return os.Rename(tmp, path)
+		})
+	}
+	return false, nil
+}
+
+// takeOver 以 O_EXCL 创建 path 对应到期时间 exp 的接管标记，确认 path 仍是该租约后执行 fn，
+// 同一过期租约只有一个实例能执行 fn；标记已存在或租约已被替换时返回 false
+func (l *FileLocker) takeOver(path string, exp time.Time, fn func() error) (bool, error) {
+	marker := fmt.Sprintf("%s.%d.steal", path, exp.UnixNano())
+	f, err := os.OpenFile(marker, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	f.Close()
+	defer os.Remove(marker)
+	cur, err := readExpiry(path)
+	if err != nil || !cur.Equal(exp) {
+		if errors.Is(err, fs.ErrNotExist) {
+			err = nil
+		}
+		return false, err
+	}
+	if err := fn(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// create 先写临时文件再硬链接到 path，path 已存在时返回 fs.ErrExist
+func (l *FileLocker) create(path string, exp time.Time) error {
+	tmp, err := l.writeTemp(exp)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	return os.Link(tmp, path)
+}
+
+// writeTemp 在锁目录写入内容为 exp 的临时文件，返回其路径
+func (l *FileLocker) writeTemp(exp time.Time) (string, error) {
+	tmp, err := os.CreateTemp(l.dir, ".tmp-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = tmp.WriteString(exp.Format(time.RFC3339Nano))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+func readExpiry(path string) (time.Time, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339Nano, strings.TrimSpace(string(data)))
+}
+
+// sweep 每分钟至多一次清理过期的租约文件与遗留的接管标记
+func (l *FileLocker) sweep(now time.Time) {
+	l.mu.Lock()
+	if now.Sub(l.swept) < time.Minute {
+		l.mu.Unlock()
+		return
+	}
+	l.swept = now
+	l.mu.Unlock()
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		path := filepath.Join(l.dir, e.Name())
+		switch {
+		case strings.HasSuffix(e.Name(), ".lock"):
+			if exp, err := readExpiry(path); err == nil && !now.Before(exp) {
+				// 与接管互斥，避免删掉刚被替换的新租约
+				l.takeOver(path, exp, func() error { return os.Remove(path) })
+			}
+		case strings.HasSuffix(e.Name(), ".steal"):
+			if info, err := e.Info(); err == nil && now.Sub(info.ModTime()) > staleMarkerAge {
+				os.Remove(path)
+			}
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package gschedule
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// secondly 在每个整秒触发，各实例计算出的计划时间一致
+type secondly struct{}
+
+func (secondly) Next(t time.Time) time.Time { return t.Truncate(time.Second).Add(time.Second) }
+
+// countingLocker 统计 TryLock 调用次数
+type countingLocker struct {
+	Locker
+	calls atomic.Int64
+}
+
+func (l *countingLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	l.calls.Add(1)
+	return l.Locker.TryLock(ctx, key, ttl)
+}
+
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before timeout")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// 本地策略不放行的实例不应获取租约，否则该次触发可能在任何实例上都不执行
+func TestLockTakenOnlyWhenAboutToRun(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		policy Concurrency
+	}{{"skip", SkipIfRunning}, {"delay", DelayIfRunning}} {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			shared := NewMemoryLocker()
+			la, lb := &countingLocker{Locker: shared}, &countingLocker{Locker: shared}
+
+			// 实例 A 的执行一直阻塞，之后的触发都被本地策略拦下
+			release := make(chan struct{})
+			a := New(4, Options{Locker: la})
+			a.AddContextJob("job", secondly{}, ContextJobFunc(func(ctx context.Context) error {
+				select {
+				case <-release:
+				case <-ctx.Done():
+				}
+				return nil
+			}), TaskOptions{Concurrency: c.policy})
+			waitFor(t, 3*time.Second, func() bool { info, _ := a.Get("job"); return info.Running == 1 })
+			calls := la.calls.Load()
+
+			var runs atomic.Int64
+			b := New(4, Options{Locker: lb})
+			b.Add("job", secondly{}, JobFunc(func() { runs.Add(1) }), TaskOptions{Concurrency: c.policy})
+			waitFor(t, 5*time.Second, func() bool { return runs.Load() >= 2 })
+
+			if n := la.calls.Load(); n != calls {
+				t.Errorf("blocked replica took %d leases", n-calls)
+			}
+			if info, _ := a.Get("job"); info.Contended != 0 {
+				t.Errorf("blocked replica contended %d times", info.Contended)
+			}
+			close(release)
+			a.Stop()
+			b.Stop()
+		})
+	}
+}
+
+func TestFileLocker(t *testing.T) {
+	dir := t.TempDir()
+	a, err := NewFileLocker(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := NewFileLocker(dir)
+	ctx := context.Background()
+	if ok, err := a.TryLock(ctx, "job/1@100", time.Minute); !ok || err != nil {
+		t.Fatalf("first TryLock = %v, %v", ok, err)
+	}
+	if ok, err := b.TryLock(ctx, "job/1@100", time.Minute); ok || err != nil {
+		t.Fatalf("held lease taken: %v, %v", ok, err)
+	}
+	if ok, _ := b.TryLock(ctx, "job/1@101", time.Minute); !ok {
+		t.Fatal("other key not acquired")
+	}
+	// 过期后可被接管
+	if ok, _ := a.TryLock(ctx, "job@200", -time.Second); !ok {
+		t.Fatal("expired lease not created")
+	}
+	if ok, err := b.TryLock(ctx, "job@200", time.Minute); !ok || err != nil {
+		t.Fatalf("expired lease not taken over: %v, %v", ok, err)
+	}
+	if ok, _ := a.TryLock(ctx, "job@200", time.Minute); ok {
+		t.Fatal("taken-over lease acquired again")
+	}
+}
+
+// 多个进程同时接管同一个过期租约时只有一个成功
+func TestFileLockerExpiredTakeoverIsExclusive(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	const rounds, replicas = 200, 16
+	// 每个实例独立，首轮调用时的 sweep 与接管并发，之后各轮走接管路径
+	lockers := make([]*FileLocker, replicas)
+	for i := range lockers {
+		var err error
+		if lockers[i], err = NewFileLocker(dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for r := range rounds {
+		key := fmt.Sprintf("job@%d", r)
+		if ok, _ := lockers[0].TryLock(ctx, key, -time.Second); !ok {
+			t.Fatal("expired lease not created")
+		}
+		var wins atomic.Int64
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		for _, l := range lockers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				ok, err := l.TryLock(ctx, key, time.Hour)
+				if err != nil {
+					t.Error(err)
+				}
+				if ok {
+					wins.Add(1)
+				}
+			}()
+		}
+		close(start)
+		wg.Wait()
+		if n := wins.Load(); n != 1 {
+			t.Fatalf("round %d: %d replicas acquired the lease", r, n)
+		}
+		if exp, err := readExpiry(filepath.Join(dir, key+".lock")); err != nil || time.Until(exp) < 59*time.Minute {
+			t.Fatalf("round %d: lease expiry %v, %v", r, exp, err)
+		}
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if filepath.Ext(e.Name()) != ".lock" {
+			t.Errorf("leftover file %s", e.Name())
+		}
+	}
+}
+
+func TestFileLockerSweep(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	l, _ := NewFileLocker(dir)
+	l.TryLock(ctx, "fresh", time.Hour)
+	l.TryLock(ctx, "expired", -time.Second)
+	old := filepath.Join(dir, "crashed.lock.1.steal")
+	recent := filepath.Join(dir, "busy.lock.2.steal")
+	for _, p := range []string{old, recent} {
+		if err := os.WriteFile(p, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	past := time.Now().Add(-2 * staleMarkerAge)
+	if err := os.Chtimes(old, past, past); err != nil {
+		t.Fatal(err)
+	}
+	// 新实例首次调用时清理
+	other, _ := NewFileLocker(dir)
+	other.TryLock(ctx, "trigger", time.Hour)
+	for name, want := range map[string]bool{
+		"fresh.lock":           true,
+		"expired.lock":         false,
+		"trigger.lock":         true,
+		"crashed.lock.1.steal": false,
+		"busy.lock.2.steal":    true,
+	} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if exists := err == nil; exists != want {
+			t.Errorf("%s exists = %v, want %v", name, exists, want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package gschedule
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrTimeout 任务执行超过 TaskOptions.Timeout
+var ErrTimeout = errors.New("gschedule: job timed out")
+
+// Concurrency 上一次执行未结束时新触发的处理策略
+type Concurrency int
+
+const (
+	// AllowConcurrent 允许同一任务并发执行（默认）
+	AllowConcurrent Concurrency = iota
+	// SkipIfRunning 跳过本次触发
+	SkipIfRunning
+	// DelayIfRunning 等上一次结束后再执行，期间的多次触发合并为一次
+	DelayIfRunning
+)
+
+// Misfire 协程池占满导致未能按时执行时的处理策略
+type Misfire int
+
+const (
+	// MisfireRunOnce 拿到令牌后立即补跑一次，等待期间错过的触发合并（默认）
+	MisfireRunOnce Misfire = iota
+	// MisfireSkip 超过 MisfireGrace 仍未拿到令牌则放弃本次触发
+	MisfireSkip
+)
+
+// TaskOptions 任务选项
+type TaskOptions struct {
+	// 并发策略
+	Concurrency Concurrency
+	// 单次执行超时，超时后取消传给 ContextJob 的 ctx 并记为 ErrTimeout；
+	// 不感知 ctx 的任务无法被中断，只记录超时
+	Timeout time.Duration
+	// 错过触发的处理策略
+	Misfire Misfire
+	// MisfireSkip 下允许的最大延迟，0 表示计划时间到点时必须有空闲令牌
+	MisfireGrace time.Duration
+}
+
+// lock 按并发策略获取执行令牌，返回 false 表示本次触发被跳过或任务已停止
+func (t *Task) lock() bool {
+	switch t.opt.Concurrency {
+	case SkipIfRunning:
+		select {
+		case t.running <- struct{}{}:
+			return true
+		default:
+			return false
+		}
+	case DelayIfRunning:
+		select {
+		case t.running <- struct{}{}:
+			return true
+		case <-t.ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+func (t *Task) unlock() {
+	if t.opt.Concurrency != AllowConcurrent {
+		<-t.running
+	}
+}
+
+// acquire 获取协程池令牌，返回 false 表示按 misfire 策略放弃或任务已停止
+func (s *Scheduler) acquire(t *Task, at time.Time) bool {
+	if t.opt.Misfire != MisfireSkip {
+		select {
+		case s.pool <- struct{}{}:
+			return true
+		case <-t.ctx.Done():
+			return false
+		}
+	}
+	select {
+	case s.pool <- struct{}{}:
+		return true
+	default:
+	}
+	wait := time.Until(at.Add(t.opt.MisfireGrace))
+	if wait <= 0 {
+		return false
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case s.pool <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-t.ctx.Done():
+		return false
+	}
+}
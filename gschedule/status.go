@@ -0,0 +1,129 @@
+package gschedule
+
+import (
+	"sync"
+	"time"
+)
+
+// RunRecord 一次执行记录
+type RunRecord struct {
+	Start time.Time
+	End   time.Time
+	Err   error
+}
+
+// Duration 执行耗时
+func (r RunRecord) Duration() time.Duration { return r.End.Sub(r.Start) }
+
+// TaskInfo 任务状态快照
+type TaskInfo struct {
+	ID        string
+	Paused    bool
+	Next      time.Time // 下一次计划触发时间，零值表示不再触发
+	LastStart time.Time
+	LastEnd   time.Time
+	LastErr   error
+	Runs      int64       // 已完成的执行次数
+	Errors    int64       // 出错（含 panic）次数
+	Running   int         // 正在执行的数量
+	Skipped   int64       // 因 SkipIfRunning 跳过的触发次数
+	Misfired  int64       // 因协程池占满按 MisfireSkip 放弃的触发次数
+	Contended int64       // 分布式锁被其他实例持有而未执行的触发次数
+	History   []RunRecord // 最近的执行记录，由旧到新
+}
+
+// taskStatus 任务运行状态，history 为定长环形缓冲
+type taskStatus struct {
+	mu        sync.Mutex
+	next      time.Time
+	start     time.Time // 最近一次开始时间，执行中即更新
+	last      RunRecord // 最近一次完成的执行
+	runs      int64
+	errors    int64
+	running   int
+	skipped   int64
+	misfired  int64
+	contended int64
+	history   []RunRecord
+	head      int
+	full      bool
+}
+
+func newTaskStatus(size int) taskStatus {
+	return taskStatus{history: make([]RunRecord, size)}
+}
+
+func (st *taskStatus) setNext(next time.Time) {
+	st.mu.Lock()
+	st.next = next
+	st.mu.Unlock()
+}
+
+func (st *taskStatus) skip() {
+	st.mu.Lock()
+	st.skipped++
+	st.mu.Unlock()
+}
+
+func (st *taskStatus) misfire() {
+	st.mu.Lock()
+	st.misfired++
+	st.mu.Unlock()
+}
+
+func (st *taskStatus) contend() {
+	st.mu.Lock()
+	st.contended++
+	st.mu.Unlock()
+}
+
+func (st *taskStatus) begin(start time.Time) {
+	st.mu.Lock()
+	st.running++
+	st.start = start
+	st.mu.Unlock()
+}
+
+func (st *taskStatus) finish(rec RunRecord) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.running--
+	st.runs++
+	if rec.Err != nil {
+		st.errors++
+	}
+	st.last = rec
+	st.history[st.head] = rec
+	st.head++
+	if st.head == len(st.history) {
+		st.head, st.full = 0, true
+	}
+}
+
+func (t *Task) info() TaskInfo {
+	t.mu.Lock()
+	paused := t.paused
+	t.mu.Unlock()
+	st := &t.status
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	info := TaskInfo{
+		ID:        t.ID,
+		Paused:    paused,
+		Next:      st.next,
+		LastStart: st.start,
+		LastEnd:   st.last.End,
+		LastErr:   st.last.Err,
+		Runs:      st.runs,
+		Errors:    st.errors,
+		Running:   st.running,
+		Skipped:   st.skipped,
+		Misfired:  st.misfired,
+		Contended: st.contended,
+	}
+	if st.full {
+		info.History = append(info.History, st.history[st.head:]...)
+	}
+	info.History = append(info.History, st.history[:st.head]...)
+	return info
+}
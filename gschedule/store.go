@@ -0,0 +1,324 @@
+package gschedule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrUnknownJobType 任务类型未注册
+var ErrUnknownJobType = errors.New("gschedule: unknown job type")
+
+// JobRecord 持久化的任务信息
+type JobRecord struct {
+	ID      string          `json:"id"`
+	Spec    string          `json:"spec"`           // cron 表达式或宏，如 "0 9 * * *"、"@every 5m"
+	Type    string          `json:"type"`           // RegisterJobType 注册的任务类型
+	Data    json.RawMessage `json:"data,omitempty"` // 传给 JobFactory 的参数
+	Options TaskOptions     `json:"options"`
+	Created time.Time       `json:"created"`
+	LastRun time.Time       `json:"last_run"`
+}
+
+// JobStore 任务持久化存储
+type JobStore interface {
+	Load() ([]JobRecord, error)
+	Save(rec JobRecord) error
+	Delete(id string) error
+}
+
+// JobFactory 根据持久化的参数创建任务
+type JobFactory func(data json.RawMessage) (ContextJob, error)
+
+var (
+	jobTypesMu sync.RWMutex
+	jobTypes   = map[string]JobFactory{}
+)
+
+// RegisterJobType 注册任务类型，恢复任务时按 JobRecord.Type 查找
+func RegisterJobType(name string, f JobFactory) {
+	jobTypesMu.Lock()
+	defer jobTypesMu.Unlock()
+	jobTypes[name] = f
+}
+
+func newJob(rec JobRecord) (ContextJob, error) {
+	jobTypesMu.RLock()
+	f, ok := jobTypes[rec.Type]
+	jobTypesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownJobType, rec.Type)
+	}
+	return f(rec.Data)
+}
+
+// CatchUp 重启后对停机期间错过的触发的处理策略
+type CatchUp int
+
+const (
+	// CatchUpNone 不补跑（默认）
+	CatchUpNone CatchUp = iota
+	// CatchUpOnce 错过多次只补跑一次
+	CatchUpOnce
+	// CatchUpAll 逐次补跑，最多 Options.MaxCatchUp 次
+	CatchUpAll
+)
+
+// DefaultMaxCatchUp CatchUpAll 默认的补跑上限
+const DefaultMaxCatchUp = 100
+
+// AddStored 添加持久化任务：解析 Spec、按 Type 创建任务并写入 Options.Store
+func (s *Scheduler) AddStored(rec JobRecord) error {
+	if rec.Created.IsZero() {
+		rec.Created = time.Now()
+	}
+	sched, job, err := resolve(rec)
+	if err != nil {
+		return err
+	}
+	// 同 id 的旧任务不再写回，避免覆盖新记录
+	s.taskMu.RLock()
+	if old, ok := s.tasks[rec.ID]; ok {
+		old.detach()
+	}
+	s.taskMu.RUnlock()
+	if s.opt.Store != nil {
+		if err := s.opt.Store.Save(rec); err != nil {
+			return err
+		}
+	}
+	s.addRecord(rec, sched, job, nil)
+	return nil
+}
+
+// Restore 从 Options.Store 恢复全部任务，并按 Options.CatchUp 补跑停机期间错过的触发
+// 单个任务恢复失败不影响其他任务，错误合并返回
+func (s *Scheduler) Restore() error {
+	if s.opt.Store == nil {
+		return nil
+	}
+	recs, err := s.opt.Store.Load()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	var errs []error
+	for _, rec := range recs {
+		sched, job, err := resolve(rec)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("gschedule: restore %s: %w", rec.ID, err))
+			continue
+		}
+		s.addRecord(rec, sched, job, s.missed(rec, sched, now))
+	}
+	return errors.Join(errs...)
+}
+
+func resolve(rec JobRecord) (Schedule, ContextJob, error) {
+	sched, err := NewCron(rec.Spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	job, err := newJob(rec)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sched, job, nil
+}
+
+// missed 返回 rec 上次执行（或创建）之后、now 之前错过的触发中需要补跑的计划时间
+// CatchUpOnce 只保留最近一次，CatchUpAll 保留最近的 MaxCatchUp 次
+func (s *Scheduler) missed(rec JobRecord, sched Schedule, now time.Time) []time.Time {
+	if s.opt.CatchUp == CatchUpNone {
+		return nil
+	}
+	from := rec.LastRun
+	if from.IsZero() {
+		from = rec.Created
+	}
+	if from.IsZero() {
+		return nil
+	}
+	limit := 1
+	if s.opt.CatchUp == CatchUpAll {
+		limit = s.opt.MaxCatchUp
+		if limit <= 0 {
+			limit = DefaultMaxCatchUp
+		}
+	}
+	var res []time.Time
+	for t := sched.Next(from); !t.IsZero() && t.Before(now); t = sched.Next(t) {
+		if len(res) == limit {
+			res = append(res[:0], res[1:]...)
+		}
+		res = append(res, t)
+	}
+	return res
+}
+
+func (s *Scheduler) addRecord(rec JobRecord, sched Schedule, job ContextJob, catchUp []time.Time) {
+	r := rec
+	s.add(rec.ID, sched, nil, job.Run, []TaskOptions{rec.Options}, &r, catchUp)
+}
+
+// persist 记录持久化任务的最近执行时间
+func (s *Scheduler) persist(t *Task, start time.Time) {
+	if s.opt.Store == nil {
+		return
+	}
+	t.recordMu.Lock()
+	defer t.recordMu.Unlock()
+	if t.record == nil {
+		return
+	}
+	t.record.LastRun = start
+	if err := s.opt.Store.Save(*t.record); err != nil && s.opt.OnError != nil {
+		s.opt.OnError(t.ID, err)
+	}
+}
+
+// detach 解除任务与持久化记录的关联
+func (t *Task) detach() {
+	t.recordMu.Lock()
+	t.record = nil
+	t.recordMu.Unlock()
+}
+
+// MemoryStore 内存存储，进程内有效，用于测试
+type MemoryStore struct {
+	mu   sync.Mutex
+	recs map[string]JobRecord
+}
+
+// NewMemoryStore 创建内存存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{recs: make(map[string]JobRecord)}
+}
+
+func (m *MemoryStore) Load() ([]JobRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return sortedRecords(m.recs), nil
+}
+
+func (m *MemoryStore) Save(rec JobRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.recs[rec.ID] = rec
+	return nil
+}
+
+func (m *MemoryStore) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.recs, id)
+	return nil
+}
+
+// FileStore JSON 文件存储，每次修改整体写临时文件后原子替换
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+	recs map[string]JobRecord
+}
+
+// NewFileStore 打开 path 处的存储，文件不存在时在首次写入时创建
+func NewFileStore(path string) (*FileStore, error) {
+	f := &FileStore{path: path, recs: make(map[string]JobRecord)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var recs []JobRecord
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &recs); err != nil {
+			return nil, fmt.Errorf("gschedule: parse %s: %w", path, err)
+		}
+	}
+	for _, rec := range recs {
+		f.recs[rec.ID] = rec
+	}
+	return f, nil
+}
+
+func (f *FileStore) Load() ([]JobRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return sortedRecords(f.recs), nil
+}
+
+func (f *FileStore) Save(rec JobRecord) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	old, had := f.recs[rec.ID]
+	f.recs[rec.ID] = rec
+	if err := f.flush(); err != nil {
+		if had {
+			f.recs[rec.ID] = old
+		} else {
+			delete(f.recs, rec.ID)
+		}
+		return err
+	}
+	return nil
+}
+
+func (f *FileStore) Delete(id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	old, had := f.recs[id]
+	if !had {
+		return nil
+	}
+	delete(f.recs, id)
+	if err := f.flush(); err != nil {
+		f.recs[id] = old
+		return err
+	}
+	return nil
+}
+
+func (f *FileStore) flush() error {
+	data, err := json.MarshalIndent(sortedRecords(f.recs), "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(f.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(f.path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
+
+func sortedRecords(m map[string]JobRecord) []JobRecord {
+	res := make([]JobRecord, 0, len(m))
+	for _, rec := range m {
+		res = append(res, rec)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	return res
+}